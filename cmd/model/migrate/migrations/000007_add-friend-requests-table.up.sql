@@ -8,6 +8,6 @@ CREATE TABLE IF NOT EXISTS friend_requests (
         FOREIGN KEY(neighbor_id)
             REFERENCES neighbors(id),
     CONSTRAINT fk_friends
-        FOREIGN KEY(requesting_friend_id)
+        FOREIGN KEY(requested_friend_id)
             REFERENCES neighbors(id)
 );
\ No newline at end of file
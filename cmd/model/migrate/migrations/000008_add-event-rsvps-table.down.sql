@@ -0,0 +1 @@
+DROP TABLE IF EXISTS event_rsvps;
\ No newline at end of file
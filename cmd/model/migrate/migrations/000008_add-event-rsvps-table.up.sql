@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS event_rsvps (
+    id SERIAL PRIMARY KEY,
+    event_id INT NOT NULL,
+    neighbor_id INT NOT NULL,
+    status VARCHAR(10) NOT NULL,
+    responded_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_events
+        FOREIGN KEY(event_id)
+            REFERENCES events(id)
+            ON DELETE CASCADE,
+    CONSTRAINT fk_neighbors
+        FOREIGN KEY(neighbor_id)
+            REFERENCES neighbors(id)
+            ON DELETE CASCADE,
+    CONSTRAINT uq_event_rsvps
+        UNIQUE(event_id, neighbor_id)
+);
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE events
+    DROP COLUMN IF EXISTS capacity;
\ No newline at end of file
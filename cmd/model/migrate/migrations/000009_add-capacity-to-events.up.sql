@@ -0,0 +1,3 @@
+/* 0 means the event has no attendance limit */
+ALTER TABLE events
+    ADD COLUMN IF NOT EXISTS capacity INT NOT NULL DEFAULT 0;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS event_invites;
\ No newline at end of file
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS event_invites (
+    id SERIAL PRIMARY KEY,
+    event_id INT NOT NULL,
+    invited_neighbor_id INT NOT NULL,
+    status VARCHAR(10) NOT NULL DEFAULT 'pending',
+    invited_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_events
+        FOREIGN KEY(event_id)
+            REFERENCES events(id)
+            ON DELETE CASCADE,
+    CONSTRAINT fk_neighbors
+        FOREIGN KEY(invited_neighbor_id)
+            REFERENCES neighbors(id)
+            ON DELETE CASCADE,
+    CONSTRAINT uq_event_invites
+        UNIQUE(event_id, invited_neighbor_id)
+);
\ No newline at end of file
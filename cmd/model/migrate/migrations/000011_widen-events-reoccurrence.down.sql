@@ -0,0 +1,2 @@
+ALTER TABLE events
+    ALTER COLUMN reoccurrence TYPE VARCHAR(14) USING LEFT(reoccurrence, 14);
\ No newline at end of file
@@ -0,0 +1,3 @@
+/* holds RRULEs like FREQ=WEEKLY;INTERVAL=2;UNTIL=20241231T235959 */
+ALTER TABLE events
+    ALTER COLUMN reoccurrence TYPE VARCHAR(255);
\ No newline at end of file
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS calendar_feeds (
+    id SERIAL PRIMARY KEY,
+    neighbor_id INT NOT NULL UNIQUE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_neighbors
+        FOREIGN KEY(neighbor_id)
+            REFERENCES neighbors(id)
+            ON DELETE CASCADE
+);
\ No newline at end of file
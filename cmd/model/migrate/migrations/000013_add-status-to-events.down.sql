@@ -0,0 +1,3 @@
+ALTER TABLE events
+    DROP COLUMN IF EXISTS status,
+    DROP COLUMN IF EXISTS cancellation_reason;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE events
+    ADD COLUMN IF NOT EXISTS status VARCHAR(10) NOT NULL DEFAULT 'scheduled',
+    ADD COLUMN IF NOT EXISTS cancellation_reason VARCHAR(255) NOT NULL DEFAULT '';
\ No newline at end of file
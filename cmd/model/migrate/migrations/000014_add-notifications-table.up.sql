@@ -0,0 +1,14 @@
+/* event_id has no foreign key so notifications outlive deleted events */
+CREATE TABLE IF NOT EXISTS notifications (
+    id SERIAL PRIMARY KEY,
+    neighbor_id INT NOT NULL,
+    event_id INT NOT NULL DEFAULT 0,
+    type VARCHAR(30) NOT NULL,
+    message VARCHAR(1000) NOT NULL,
+    read BOOLEAN DEFAULT 'false' NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_neighbors
+        FOREIGN KEY(neighbor_id)
+            REFERENCES neighbors(id)
+            ON DELETE CASCADE
+);
\ No newline at end of file
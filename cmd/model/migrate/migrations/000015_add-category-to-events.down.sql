@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS events_start_id_idx;
+ALTER TABLE events
+    DROP COLUMN IF EXISTS category;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE events
+    ADD COLUMN IF NOT EXISTS category VARCHAR(30) NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS events_start_id_idx ON events (start, id);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS neighbor_tokens;
\ No newline at end of file
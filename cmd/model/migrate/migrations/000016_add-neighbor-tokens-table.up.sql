@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS neighbor_tokens (
+    id SERIAL PRIMARY KEY,
+    neighbor_id INT NOT NULL,
+    purpose VARCHAR(20) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    used BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_neighbors
+        FOREIGN KEY(neighbor_id)
+            REFERENCES neighbors(id)
+            ON DELETE CASCADE
+);
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE neighbors
+    DROP COLUMN IF EXISTS token_version;
\ No newline at end of file
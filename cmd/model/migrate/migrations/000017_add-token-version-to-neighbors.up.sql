@@ -0,0 +1,2 @@
+ALTER TABLE neighbors
+    ADD COLUMN IF NOT EXISTS token_version INT NOT NULL DEFAULT 0;
\ No newline at end of file
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS sessions (
+    id SERIAL PRIMARY KEY,
+    neighbor_id INT NOT NULL,
+    jti VARCHAR(64) NOT NULL UNIQUE,
+    refresh_token_hash VARCHAR(64) NOT NULL UNIQUE,
+    previous_refresh_token_hash VARCHAR(64) NOT NULL DEFAULT '',
+    expires_at TIMESTAMP NOT NULL,
+    revoked BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_neighbors
+        FOREIGN KEY(neighbor_id)
+            REFERENCES neighbors(id)
+            ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS sessions_previous_refresh_token_hash_idx ON sessions (previous_refresh_token_hash);
\ No newline at end of file
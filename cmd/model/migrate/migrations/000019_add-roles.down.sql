@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS neighborhood_moderators;
+ALTER TABLE neighbors
+    DROP COLUMN IF EXISTS role;
\ No newline at end of file
@@ -0,0 +1,18 @@
+ALTER TABLE neighbors
+    ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'member'
+        CHECK (role IN ('admin', 'member'));
+CREATE TABLE IF NOT EXISTS neighborhood_moderators (
+    id SERIAL PRIMARY KEY,
+    neighborhood_id INT NOT NULL,
+    neighbor_id INT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (neighborhood_id, neighbor_id),
+    CONSTRAINT fk_neighborhoods
+        FOREIGN KEY(neighborhood_id)
+            REFERENCES neighborhoods(id)
+            ON DELETE CASCADE,
+    CONSTRAINT fk_neighbors
+        FOREIGN KEY(neighbor_id)
+            REFERENCES neighbors(id)
+            ON DELETE CASCADE
+);
\ No newline at end of file
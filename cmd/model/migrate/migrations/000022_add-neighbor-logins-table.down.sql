@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS neighbor_logins;
+ALTER TABLE neighbors
+    ALTER COLUMN ip TYPE VARCHAR(40);
\ No newline at end of file
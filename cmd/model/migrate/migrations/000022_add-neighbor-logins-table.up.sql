@@ -0,0 +1,14 @@
+ALTER TABLE neighbors
+    ALTER COLUMN ip TYPE VARCHAR(45);
+CREATE TABLE IF NOT EXISTS neighbor_logins (
+    id SERIAL PRIMARY KEY,
+    neighbor_id INT NOT NULL,
+    ip VARCHAR(45) NOT NULL,
+    user_agent VARCHAR(255) NOT NULL DEFAULT '',
+    logged_in_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_neighbors
+        FOREIGN KEY(neighbor_id)
+            REFERENCES neighbors(id)
+            ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS neighbor_logins_neighbor_id_idx ON neighbor_logins (neighbor_id, id);
\ No newline at end of file
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS account_deletions (
+    neighbor_id INT PRIMARY KEY,
+    requested_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    purge_after TIMESTAMP NOT NULL,
+    CONSTRAINT fk_neighbors
+        FOREIGN KEY(neighbor_id)
+            REFERENCES neighbors(id)
+            ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS account_deletions_purge_after_idx ON account_deletions (purge_after);
\ No newline at end of file
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS politics;
+DROP TABLE IF EXISTS religions;
+DROP TABLE IF EXISTS relationship_statuses;
+DROP TABLE IF EXISTS ethnicities;
+DROP TABLE IF EXISTS races;
+DROP TABLE IF EXISTS genders;
+DROP TABLE IF EXISTS dates_of_birth;
+DROP TABLE IF EXISTS bios;
\ No newline at end of file
@@ -0,0 +1,87 @@
+CREATE TABLE IF NOT EXISTS bios (
+    id SERIAL PRIMARY KEY,
+    neighbor_id INT NOT NULL UNIQUE,
+    bio TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_neighbors
+        FOREIGN KEY(neighbor_id)
+            REFERENCES neighbors(id)
+            ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS dates_of_birth (
+    id SERIAL PRIMARY KEY,
+    neighbor_id INT NOT NULL UNIQUE,
+    date_of_birth DATE NOT NULL,
+    public BOOLEAN NOT NULL DEFAULT FALSE,
+    recorded_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_neighbors
+        FOREIGN KEY(neighbor_id)
+            REFERENCES neighbors(id)
+            ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS genders (
+    id SERIAL PRIMARY KEY,
+    neighbor_id INT NOT NULL UNIQUE,
+    gender VARCHAR(100) NOT NULL DEFAULT '',
+    public BOOLEAN NOT NULL DEFAULT FALSE,
+    recorded_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_neighbors
+        FOREIGN KEY(neighbor_id)
+            REFERENCES neighbors(id)
+            ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS races (
+    id SERIAL PRIMARY KEY,
+    neighbor_id INT NOT NULL UNIQUE,
+    race VARCHAR(100) NOT NULL DEFAULT '',
+    public BOOLEAN NOT NULL DEFAULT FALSE,
+    recorded_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_neighbors
+        FOREIGN KEY(neighbor_id)
+            REFERENCES neighbors(id)
+            ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS ethnicities (
+    id SERIAL PRIMARY KEY,
+    neighbor_id INT NOT NULL UNIQUE,
+    ethnicity VARCHAR(100) NOT NULL DEFAULT '',
+    public BOOLEAN NOT NULL DEFAULT FALSE,
+    recorded_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_neighbors
+        FOREIGN KEY(neighbor_id)
+            REFERENCES neighbors(id)
+            ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS relationship_statuses (
+    id SERIAL PRIMARY KEY,
+    neighbor_id INT NOT NULL UNIQUE,
+    relationship_status VARCHAR(100) NOT NULL DEFAULT '',
+    public BOOLEAN NOT NULL DEFAULT FALSE,
+    recorded_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_neighbors
+        FOREIGN KEY(neighbor_id)
+            REFERENCES neighbors(id)
+            ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS religions (
+    id SERIAL PRIMARY KEY,
+    neighbor_id INT NOT NULL UNIQUE,
+    religion VARCHAR(100) NOT NULL DEFAULT '',
+    public BOOLEAN NOT NULL DEFAULT FALSE,
+    recorded_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_neighbors
+        FOREIGN KEY(neighbor_id)
+            REFERENCES neighbors(id)
+            ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS politics (
+    id SERIAL PRIMARY KEY,
+    neighbor_id INT NOT NULL UNIQUE,
+    politics VARCHAR(100) NOT NULL DEFAULT '',
+    public BOOLEAN NOT NULL DEFAULT FALSE,
+    recorded_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_neighbors
+        FOREIGN KEY(neighbor_id)
+            REFERENCES neighbors(id)
+            ON DELETE CASCADE
+);
\ No newline at end of file
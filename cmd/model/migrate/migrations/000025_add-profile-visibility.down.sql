@@ -0,0 +1,21 @@
+ALTER TABLE dates_of_birth ADD COLUMN public BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE dates_of_birth SET public = TRUE WHERE visibility = 'public';
+ALTER TABLE dates_of_birth DROP COLUMN visibility;
+ALTER TABLE genders ADD COLUMN public BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE genders SET public = TRUE WHERE visibility = 'public';
+ALTER TABLE genders DROP COLUMN visibility;
+ALTER TABLE races ADD COLUMN public BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE races SET public = TRUE WHERE visibility = 'public';
+ALTER TABLE races DROP COLUMN visibility;
+ALTER TABLE ethnicities ADD COLUMN public BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE ethnicities SET public = TRUE WHERE visibility = 'public';
+ALTER TABLE ethnicities DROP COLUMN visibility;
+ALTER TABLE relationship_statuses ADD COLUMN public BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE relationship_statuses SET public = TRUE WHERE visibility = 'public';
+ALTER TABLE relationship_statuses DROP COLUMN visibility;
+ALTER TABLE religions ADD COLUMN public BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE religions SET public = TRUE WHERE visibility = 'public';
+ALTER TABLE religions DROP COLUMN visibility;
+ALTER TABLE politics ADD COLUMN public BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE politics SET public = TRUE WHERE visibility = 'public';
+ALTER TABLE politics DROP COLUMN visibility;
\ No newline at end of file
@@ -0,0 +1,35 @@
+ALTER TABLE dates_of_birth
+    ADD COLUMN visibility VARCHAR(20) NOT NULL DEFAULT 'private'
+        CHECK (visibility IN ('private', 'friends', 'neighborhood', 'public'));
+UPDATE dates_of_birth SET visibility = 'public' WHERE public;
+ALTER TABLE dates_of_birth DROP COLUMN public;
+ALTER TABLE genders
+    ADD COLUMN visibility VARCHAR(20) NOT NULL DEFAULT 'private'
+        CHECK (visibility IN ('private', 'friends', 'neighborhood', 'public'));
+UPDATE genders SET visibility = 'public' WHERE public;
+ALTER TABLE genders DROP COLUMN public;
+ALTER TABLE races
+    ADD COLUMN visibility VARCHAR(20) NOT NULL DEFAULT 'private'
+        CHECK (visibility IN ('private', 'friends', 'neighborhood', 'public'));
+UPDATE races SET visibility = 'public' WHERE public;
+ALTER TABLE races DROP COLUMN public;
+ALTER TABLE ethnicities
+    ADD COLUMN visibility VARCHAR(20) NOT NULL DEFAULT 'private'
+        CHECK (visibility IN ('private', 'friends', 'neighborhood', 'public'));
+UPDATE ethnicities SET visibility = 'public' WHERE public;
+ALTER TABLE ethnicities DROP COLUMN public;
+ALTER TABLE relationship_statuses
+    ADD COLUMN visibility VARCHAR(20) NOT NULL DEFAULT 'private'
+        CHECK (visibility IN ('private', 'friends', 'neighborhood', 'public'));
+UPDATE relationship_statuses SET visibility = 'public' WHERE public;
+ALTER TABLE relationship_statuses DROP COLUMN public;
+ALTER TABLE religions
+    ADD COLUMN visibility VARCHAR(20) NOT NULL DEFAULT 'private'
+        CHECK (visibility IN ('private', 'friends', 'neighborhood', 'public'));
+UPDATE religions SET visibility = 'public' WHERE public;
+ALTER TABLE religions DROP COLUMN public;
+ALTER TABLE politics
+    ADD COLUMN visibility VARCHAR(20) NOT NULL DEFAULT 'private'
+        CHECK (visibility IN ('private', 'friends', 'neighborhood', 'public'));
+UPDATE politics SET visibility = 'public' WHERE public;
+ALTER TABLE politics DROP COLUMN public;
\ No newline at end of file
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS interests;
+DROP TABLE IF EXISTS interest_taxonomy;
+DROP TABLE IF EXISTS occupations;
+DROP TABLE IF EXISTS education;
\ No newline at end of file
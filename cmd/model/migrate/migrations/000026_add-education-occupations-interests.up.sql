@@ -0,0 +1,78 @@
+CREATE TABLE IF NOT EXISTS education (
+    id SERIAL PRIMARY KEY,
+    neighbor_id INT NOT NULL,
+    school VARCHAR(255) NOT NULL,
+    degree VARCHAR(255) NOT NULL DEFAULT '',
+    start_date DATE NOT NULL,
+    end_date DATE,
+    visibility VARCHAR(20) NOT NULL DEFAULT 'private'
+        CHECK (visibility IN ('private', 'friends', 'neighborhood', 'public')),
+    recorded_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (end_date IS NULL OR end_date >= start_date),
+    CONSTRAINT fk_neighbors
+        FOREIGN KEY(neighbor_id)
+            REFERENCES neighbors(id)
+            ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS education_neighbor_id_idx ON education (neighbor_id);
+CREATE TABLE IF NOT EXISTS occupations (
+    id SERIAL PRIMARY KEY,
+    neighbor_id INT NOT NULL,
+    role VARCHAR(255) NOT NULL,
+    employer VARCHAR(255) NOT NULL DEFAULT '',
+    start_date DATE NOT NULL,
+    end_date DATE,
+    visibility VARCHAR(20) NOT NULL DEFAULT 'private'
+        CHECK (visibility IN ('private', 'friends', 'neighborhood', 'public')),
+    recorded_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (end_date IS NULL OR end_date >= start_date),
+    CONSTRAINT fk_neighbors
+        FOREIGN KEY(neighbor_id)
+            REFERENCES neighbors(id)
+            ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS occupations_neighbor_id_idx ON occupations (neighbor_id);
+CREATE TABLE IF NOT EXISTS interest_taxonomy (
+    id SERIAL PRIMARY KEY,
+    interest VARCHAR(100) NOT NULL UNIQUE,
+    category VARCHAR(100) NOT NULL
+);
+INSERT INTO interest_taxonomy (interest, category) VALUES
+    ('Basketball', 'Sports'),
+    ('Running', 'Sports'),
+    ('Soccer', 'Sports'),
+    ('Yoga', 'Sports'),
+    ('Cooking', 'Food & Drink'),
+    ('Baking', 'Food & Drink'),
+    ('Wine', 'Food & Drink'),
+    ('Gardening', 'Home & Garden'),
+    ('Home Improvement', 'Home & Garden'),
+    ('Board Games', 'Games'),
+    ('Video Games', 'Games'),
+    ('Reading', 'Arts & Culture'),
+    ('Music', 'Arts & Culture'),
+    ('Painting', 'Arts & Culture'),
+    ('Photography', 'Arts & Culture'),
+    ('Hiking', 'Outdoors'),
+    ('Camping', 'Outdoors'),
+    ('Cycling', 'Outdoors'),
+    ('Volunteering', 'Community'),
+    ('Parenting', 'Community'),
+    ('Pets', 'Community')
+ON CONFLICT (interest) DO NOTHING;
+CREATE TABLE IF NOT EXISTS interests (
+    id SERIAL PRIMARY KEY,
+    neighbor_id INT NOT NULL,
+    interest_id INT NOT NULL,
+    recorded_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (neighbor_id, interest_id),
+    CONSTRAINT fk_neighbors
+        FOREIGN KEY(neighbor_id)
+            REFERENCES neighbors(id)
+            ON DELETE CASCADE,
+    CONSTRAINT fk_interest_taxonomy
+        FOREIGN KEY(interest_id)
+            REFERENCES interest_taxonomy(id)
+            ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS interests_interest_id_idx ON interests (interest_id);
\ No newline at end of file
@@ -0,0 +1,48 @@
+DROP INDEX IF EXISTS bios_neighbor_id_created_at_idx;
+DELETE FROM bios older
+    USING bios newer
+    WHERE older.neighbor_id = newer.neighbor_id
+    AND (older.created_at, older.id) < (newer.created_at, newer.id);
+ALTER TABLE bios ADD CONSTRAINT bios_neighbor_id_key UNIQUE (neighbor_id);
+DROP INDEX IF EXISTS dates_of_birth_neighbor_id_recorded_at_idx;
+DELETE FROM dates_of_birth older
+    USING dates_of_birth newer
+    WHERE older.neighbor_id = newer.neighbor_id
+    AND (older.recorded_at, older.id) < (newer.recorded_at, newer.id);
+ALTER TABLE dates_of_birth ADD CONSTRAINT dates_of_birth_neighbor_id_key UNIQUE (neighbor_id);
+DROP INDEX IF EXISTS genders_neighbor_id_recorded_at_idx;
+DELETE FROM genders older
+    USING genders newer
+    WHERE older.neighbor_id = newer.neighbor_id
+    AND (older.recorded_at, older.id) < (newer.recorded_at, newer.id);
+ALTER TABLE genders ADD CONSTRAINT genders_neighbor_id_key UNIQUE (neighbor_id);
+DROP INDEX IF EXISTS races_neighbor_id_recorded_at_idx;
+DELETE FROM races older
+    USING races newer
+    WHERE older.neighbor_id = newer.neighbor_id
+    AND (older.recorded_at, older.id) < (newer.recorded_at, newer.id);
+ALTER TABLE races ADD CONSTRAINT races_neighbor_id_key UNIQUE (neighbor_id);
+DROP INDEX IF EXISTS ethnicities_neighbor_id_recorded_at_idx;
+DELETE FROM ethnicities older
+    USING ethnicities newer
+    WHERE older.neighbor_id = newer.neighbor_id
+    AND (older.recorded_at, older.id) < (newer.recorded_at, newer.id);
+ALTER TABLE ethnicities ADD CONSTRAINT ethnicities_neighbor_id_key UNIQUE (neighbor_id);
+DROP INDEX IF EXISTS relationship_statuses_neighbor_id_recorded_at_idx;
+DELETE FROM relationship_statuses older
+    USING relationship_statuses newer
+    WHERE older.neighbor_id = newer.neighbor_id
+    AND (older.recorded_at, older.id) < (newer.recorded_at, newer.id);
+ALTER TABLE relationship_statuses ADD CONSTRAINT relationship_statuses_neighbor_id_key UNIQUE (neighbor_id);
+DROP INDEX IF EXISTS religions_neighbor_id_recorded_at_idx;
+DELETE FROM religions older
+    USING religions newer
+    WHERE older.neighbor_id = newer.neighbor_id
+    AND (older.recorded_at, older.id) < (newer.recorded_at, newer.id);
+ALTER TABLE religions ADD CONSTRAINT religions_neighbor_id_key UNIQUE (neighbor_id);
+DROP INDEX IF EXISTS politics_neighbor_id_recorded_at_idx;
+DELETE FROM politics older
+    USING politics newer
+    WHERE older.neighbor_id = newer.neighbor_id
+    AND (older.recorded_at, older.id) < (newer.recorded_at, newer.id);
+ALTER TABLE politics ADD CONSTRAINT politics_neighbor_id_key UNIQUE (neighbor_id);
\ No newline at end of file
@@ -0,0 +1,16 @@
+ALTER TABLE bios DROP CONSTRAINT IF EXISTS bios_neighbor_id_key;
+CREATE INDEX IF NOT EXISTS bios_neighbor_id_created_at_idx ON bios (neighbor_id, created_at DESC, id DESC);
+ALTER TABLE dates_of_birth DROP CONSTRAINT IF EXISTS dates_of_birth_neighbor_id_key;
+CREATE INDEX IF NOT EXISTS dates_of_birth_neighbor_id_recorded_at_idx ON dates_of_birth (neighbor_id, recorded_at DESC, id DESC);
+ALTER TABLE genders DROP CONSTRAINT IF EXISTS genders_neighbor_id_key;
+CREATE INDEX IF NOT EXISTS genders_neighbor_id_recorded_at_idx ON genders (neighbor_id, recorded_at DESC, id DESC);
+ALTER TABLE races DROP CONSTRAINT IF EXISTS races_neighbor_id_key;
+CREATE INDEX IF NOT EXISTS races_neighbor_id_recorded_at_idx ON races (neighbor_id, recorded_at DESC, id DESC);
+ALTER TABLE ethnicities DROP CONSTRAINT IF EXISTS ethnicities_neighbor_id_key;
+CREATE INDEX IF NOT EXISTS ethnicities_neighbor_id_recorded_at_idx ON ethnicities (neighbor_id, recorded_at DESC, id DESC);
+ALTER TABLE relationship_statuses DROP CONSTRAINT IF EXISTS relationship_statuses_neighbor_id_key;
+CREATE INDEX IF NOT EXISTS relationship_statuses_neighbor_id_recorded_at_idx ON relationship_statuses (neighbor_id, recorded_at DESC, id DESC);
+ALTER TABLE religions DROP CONSTRAINT IF EXISTS religions_neighbor_id_key;
+CREATE INDEX IF NOT EXISTS religions_neighbor_id_recorded_at_idx ON religions (neighbor_id, recorded_at DESC, id DESC);
+ALTER TABLE politics DROP CONSTRAINT IF EXISTS politics_neighbor_id_key;
+CREATE INDEX IF NOT EXISTS politics_neighbor_id_recorded_at_idx ON politics (neighbor_id, recorded_at DESC, id DESC);
\ No newline at end of file
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS event_covers;
+DROP TABLE IF EXISTS avatars;
+DROP TABLE IF EXISTS images;
\ No newline at end of file
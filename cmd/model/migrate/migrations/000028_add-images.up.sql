@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS images (
+    id SERIAL PRIMARY KEY,
+    blob_key VARCHAR(255) NOT NULL UNIQUE,
+    thumbnail_key VARCHAR(255) NOT NULL UNIQUE,
+    content_type VARCHAR(50) NOT NULL,
+    width INT NOT NULL,
+    height INT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE IF NOT EXISTS avatars (
+    neighbor_id INT PRIMARY KEY,
+    image_id INT NOT NULL,
+    CONSTRAINT fk_neighbors
+        FOREIGN KEY(neighbor_id)
+            REFERENCES neighbors(id)
+            ON DELETE CASCADE,
+    CONSTRAINT fk_images
+        FOREIGN KEY(image_id)
+            REFERENCES images(id)
+            ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS event_covers (
+    event_id INT PRIMARY KEY,
+    image_id INT NOT NULL,
+    CONSTRAINT fk_events
+        FOREIGN KEY(event_id)
+            REFERENCES events(id)
+            ON DELETE CASCADE,
+    CONSTRAINT fk_images
+        FOREIGN KEY(image_id)
+            REFERENCES images(id)
+            ON DELETE CASCADE
+);
\ No newline at end of file
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS blocks (
+    id SERIAL PRIMARY KEY,
+    neighbor_id INT NOT NULL,
+    blocked_neighbor_id INT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (neighbor_id, blocked_neighbor_id),
+    CHECK (neighbor_id <> blocked_neighbor_id),
+    CONSTRAINT fk_neighbors
+        FOREIGN KEY(neighbor_id)
+            REFERENCES neighbors(id)
+            ON DELETE CASCADE,
+    CONSTRAINT fk_blocked_neighbors
+        FOREIGN KEY(blocked_neighbor_id)
+            REFERENCES neighbors(id)
+            ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS blocks_blocked_neighbor_id_idx ON blocks (blocked_neighbor_id);
\ No newline at end of file
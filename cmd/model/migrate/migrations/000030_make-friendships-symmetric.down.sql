@@ -0,0 +1,7 @@
+ALTER TABLE friend_requests
+    DROP CONSTRAINT IF EXISTS friend_requests_not_self_check,
+    DROP CONSTRAINT IF EXISTS friend_requests_neighbor_id_requested_friend_id_key;
+
+ALTER TABLE friends
+    DROP CONSTRAINT IF EXISTS friends_ordered_check,
+    DROP CONSTRAINT IF EXISTS friends_neighbor_id_neighbors_friend_id_key;
\ No newline at end of file
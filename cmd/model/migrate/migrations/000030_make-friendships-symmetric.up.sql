@@ -0,0 +1,27 @@
+DELETE FROM friends f
+USING friends g
+WHERE LEAST(f.neighbor_id, f.neighbors_friend_id) = LEAST(g.neighbor_id, g.neighbors_friend_id)
+AND GREATEST(f.neighbor_id, f.neighbors_friend_id) = GREATEST(g.neighbor_id, g.neighbors_friend_id)
+AND f.id > g.id;
+
+DELETE FROM friends WHERE neighbor_id = neighbors_friend_id;
+
+UPDATE friends
+SET neighbor_id = neighbors_friend_id, neighbors_friend_id = neighbor_id
+WHERE neighbor_id > neighbors_friend_id;
+
+ALTER TABLE friends
+    ADD CONSTRAINT friends_neighbor_id_neighbors_friend_id_key UNIQUE (neighbor_id, neighbors_friend_id),
+    ADD CONSTRAINT friends_ordered_check CHECK (neighbor_id < neighbors_friend_id);
+
+DELETE FROM friend_requests f
+USING friend_requests g
+WHERE f.neighbor_id = g.neighbor_id
+AND f.requested_friend_id = g.requested_friend_id
+AND f.id < g.id;
+
+DELETE FROM friend_requests WHERE neighbor_id = requested_friend_id;
+
+ALTER TABLE friend_requests
+    ADD CONSTRAINT friend_requests_neighbor_id_requested_friend_id_key UNIQUE (neighbor_id, requested_friend_id),
+    ADD CONSTRAINT friend_requests_not_self_check CHECK (neighbor_id <> requested_friend_id);
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE interests DROP COLUMN IF EXISTS visibility;
\ No newline at end of file
@@ -0,0 +1,4 @@
+ALTER TABLE interests
+    ADD COLUMN visibility VARCHAR(20) NOT NULL DEFAULT 'private'
+        CHECK (visibility IN ('private', 'friends', 'neighborhood', 'public'));
+UPDATE interests SET visibility = 'public';
\ No newline at end of file
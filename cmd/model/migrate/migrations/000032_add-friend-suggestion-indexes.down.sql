@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS events_host_id_idx;
+DROP INDEX IF EXISTS event_rsvps_neighbor_id_idx;
+DROP INDEX IF EXISTS friends_neighbors_friend_id_idx;
+DROP INDEX IF EXISTS neighbors_zipcode_idx;
+DROP INDEX IF EXISTS addresses_neighborhood_id_idx;
+DROP INDEX IF EXISTS addresses_neighbor_id_recorded_at_idx;
\ No newline at end of file
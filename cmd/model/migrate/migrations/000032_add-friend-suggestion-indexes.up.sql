@@ -0,0 +1,6 @@
+CREATE INDEX IF NOT EXISTS addresses_neighbor_id_recorded_at_idx ON addresses (neighbor_id, recorded_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS addresses_neighborhood_id_idx ON addresses (neighborhood_id);
+CREATE INDEX IF NOT EXISTS neighbors_zipcode_idx ON neighbors (zipcode);
+CREATE INDEX IF NOT EXISTS friends_neighbors_friend_id_idx ON friends (neighbors_friend_id);
+CREATE INDEX IF NOT EXISTS event_rsvps_neighbor_id_idx ON event_rsvps (neighbor_id);
+CREATE INDEX IF NOT EXISTS events_host_id_idx ON events (host_id);
\ No newline at end of file
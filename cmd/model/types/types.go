@@ -1,6 +1,7 @@
 package types
 
 import (
+	"io"
 	"time"
 )
 
@@ -13,12 +14,26 @@ type ZipcodeStore interface {
 type NeighborStore interface {
 	GetNeighborWithEmailOrUsername(emailOrUsername string) (*Neighbors, error)
 	GetNeighborById(id int) (*Neighbors, error)
+	GetNeighborhoodIdById(id int) (int, error)
 	CreateNeighbor(Neighbors) error
 	GetNeighborWithEmail(email string) (*Neighbors, error)
 	GetNeighborWithUsername(username string) (*Neighbors, error)
 	UpdateZipcodeWithId(Neighbors) error
 	UpdatePasswordWithId(Neighbors) error
 	UpdateVerifiedWithId(Neighbors) error
+	UpdateAccountWithId(Neighbors) error
+	UpdateRoleWithId(Neighbors) error
+	IsModerator(neighborId int, neighborhoodId int) (bool, error)
+	CreateLogin(NeighborLogins) error
+	GetLoginsByNeighborId(neighborId int, page Pagination) ([]NeighborLogins, error)
+	CreateSession(session Sessions, expiresIn time.Duration) error
+	GetSessionByJti(jti string) (*Sessions, error)
+	RotateSession(refreshTokenHash string, session Sessions, expiresIn time.Duration) (*Sessions, error)
+	RevokeSession(jti string) error
+	RevokeSessionsByNeighborId(neighborId int) error
+	ScheduleDeletion(neighborId int, gracePeriod time.Duration) (*AccountDeletions, error)
+	CancelDeletion(neighborId int) (bool, error)
+	PurgeDueDeletions() (int, error)
 }
 
 type AddressStore interface {
@@ -33,42 +48,139 @@ type AddressStore interface {
 		neighborId int,
 	) (*Addresses, error)
 	GetAddressByNeighborId(id int) (*Addresses, error)
-	GetAddressesByNeighborId(id int) ([]Addresses, error)
+	GetAddressesByNeighborId(id int, page Pagination) ([]Addresses, error)
 }
 
 type EventStore interface {
-	GetPublicEvents() ([]Events, error)
-	GetEventsByZipcode(zipcode string, dateTime time.Time) ([]EventAddresses, error)
-	GetZipcodeEventsOnDate(zipcode string, dateTime time.Time) ([]EventAddresses, error)
-	GetZipcodeEventsBeforeDate(zipcode string, dateTime time.Time) ([]EventAddresses, error)
-	GetZipcodeEventsAfterDate(zipcode string, dateTime time.Time) ([]EventAddresses, error)
-	GetEventsByNeighborhoodId(neighborhoodId int, dateTime time.Time) ([]EventAddresses, error)
-	GetNeighborhoodEventsOnDate(neighborhoodId int, dateTime time.Time) ([]EventAddresses, error)
-	GetNeighborhoodEventsBeforeDate(neighborhoodId int, dateTime time.Time) ([]EventAddresses, error)
-	GetNeighborhoodEventsAfterDate(neighborhoodId int, dateTime time.Time) ([]EventAddresses, error)
-	GetEventsByCity(city string, state string, dateTime time.Time) ([]EventAddresses, error)
-	GetCityEventsOnDate(city string, state string, zipcode string, dateTime time.Time) ([]EventAddresses, error)
-	GetCityEventsBeforeDate(city string, state string, zipcode string, dateTime time.Time) ([]EventAddresses, error)
-	GetCityEventsAfterDate(city string, state string, zipcode string, dateTime time.Time) ([]EventAddresses, error)
+	GetPublicEvents(limit int, cursor *EventCursor) ([]Events, error)
+	QueryEvents(EventQuery) ([]EventAddresses, error)
 	// GetAllEvents(dateTime time.Time) ([]EventAddresses, error)
 	CreateEvent(Events) error
+	GetEventById(id int) (*Events, error)
+	GetEventNeighborhoodId(id int) (int, error)
+	RespondToEvent(EventRsvps) (*EventRsvps, error)
+	CancelRsvp(eventId int, neighborId int) error
+	GetAttendeesByEventId(eventId int) ([]EventAttendees, error)
+	CreateEventInvite(EventInvites) error
+	GetEventInvite(eventId int, neighborId int) (*EventInvites, error)
+	GetEventInvitesByNeighborId(neighborId int) ([]NeighborEventInvites, error)
+	GetInvitedEventIds(neighborId int, eventIds []int) ([]int, error)
+	UpdateEventInvite(EventInvites) error
+	GetEventWithAddressById(id int) (*EventAddresses, error)
+	UpdateEvent(Events) error
+	CancelEvent(Events) error
+	DeleteEvent(id int) error
+	GetEventsByHostId(hostId int) ([]Events, error)
+	GetRsvpsByNeighborId(neighborId int) ([]EventRsvps, error)
+}
+
+type NotificationStore interface {
+	CreateEventNotifications(Notifications) error
+	GetNotificationsByNeighborId(neighborId int, page Pagination) ([]Notifications, error)
+	UpdateNotificationRead(Notifications) error
+}
+
+type CalendarStore interface {
+	UpsertCalendarFeed(CalendarFeeds) error
+	GetCalendarFeedByTokenHash(tokenHash string) (*CalendarFeeds, error)
+	DeleteCalendarFeed(neighborId int) error
+}
+
+type TokenStore interface {
+	CreateNeighborToken(token NeighborTokens, expiresIn time.Duration) error
+	ConsumeNeighborToken(purpose string, tokenHash string) (*NeighborTokens, error)
+}
+
+type TwoFactorStore interface {
+	GetTwoFactorByNeighborId(neighborId int) (*TwoFactors, error)
+	CreateTwoFactor(TwoFactors) error
+	EnableTwoFactor(neighborId int, recoveryCodeHashes []string) error
+	UseTwoFactorStep(neighborId int, step int64) (bool, error)
+	UseRecoveryCode(neighborId int, codeHash string) (bool, error)
+	DeleteTwoFactor(neighborId int) error
+}
+
+// LoginAttemptStore counts failed logins per key, failures older than the window are forgotten
+type LoginAttemptStore interface {
+	GetLoginLock(key string) (time.Duration, error)
+	RecordFailedLogin(key string, window time.Duration) (int, error)
+	LockLogin(key string, lockFor time.Duration) error
+	ClearLoginAttempts(key string) error
 }
 
 type FriendStore interface {
-	GetFriendsByNeighborId(neighborId int) ([]FriendsList, error)
-	GetFriendRequestsByNeighborId(requestedFriendId int) ([]PendingFriendRequests, error)
-	CreateFriendRequest(FriendRequests) error
-	UpdateFriendRequest(FriendRequests) error
+	GetFriendsByNeighborId(neighborId int, page Pagination) ([]FriendsList, error)
+	GetFriendRequestsByNeighborId(requestedFriendId int, page Pagination) ([]PendingFriendRequests, error)
+	CreateFriendRequest(FriendRequests) (string, error)
+	UpdateFriendRequest(FriendRequests) (bool, error)
+	AcceptFriendRequest(FriendRequests) (bool, error)
 	CreateFriend(Friends) error
+	GetFriendIds(neighborId int, neighborIds []int) ([]int, error)
+	AreFriends(neighborId int, friendId int) (bool, error)
+	DeleteFriend(neighborId int, friendId int) (bool, error)
+	DeleteFriendRequest(neighborId int, requestedFriendId int) (bool, error)
+	GetBlocksByNeighborId(neighborId int) ([]Blocks, error)
+	GetBlockerIds(neighborId int, neighborIds []int) ([]int, error)
+	IsBlocked(neighborId int, blockedNeighborId int) (bool, error)
+	CreateBlock(Blocks) error
+	DeleteBlock(neighborId int, blockedNeighborId int) (bool, error)
+	GetFriendshipsByNeighborId(neighborId int) ([]Friends, error)
+	GetAllFriendRequestsByNeighborId(neighborId int) ([]FriendRequests, error)
+	GetFriendSuggestions(neighborId int, limit int, cursor *SuggestionCursor) ([]FriendSuggestions, error)
 }
 
 type ProfileStore interface {
 	GetProfileByNeighborId(neighborId int) (*Profiles, error)
+	CreateBio(bio Bios) error
+	CreateDateOfBirth(dateOfBirth DatesOfBirth) error
+	CreateGender(gender Genders) error
+	CreateRace(race Races) error
+	CreateEthnicity(ethnicity Ethnicities) error
+	CreateRelationshipStatus(relationshipStatus RelationshipStatuses) error
+	CreateReligion(religion Religions) error
+	CreatePolitics(politics Politics) error
+	GetProfileHistoryByNeighborId(neighborId int) ([]ProfileHistory, error)
+	GetEducationByNeighborId(neighborId int) ([]Education, error)
+	CreateEducation(Education) error
+	UpdateEducation(Education) (bool, error)
+	DeleteEducation(id int, neighborId int) (bool, error)
+	GetOccupationsByNeighborId(neighborId int) ([]Occupations, error)
+	CreateOccupation(Occupations) error
+	UpdateOccupation(Occupations) (bool, error)
+	DeleteOccupation(id int, neighborId int) (bool, error)
+	GetInterestsByNeighborId(neighborId int) ([]Interests, error)
+	CreateInterest(Interests) error
+	DeleteInterest(neighborId int, interestId int) (bool, error)
+	GetInterestTaxonomy() ([]InterestTaxonomy, error)
+	CreateInterestTaxonomy(InterestTaxonomy) error
+}
+
+// BlobStore holds uploaded files by key, deleting a key that isn't there isn't an error
+type BlobStore interface {
+	PutBlob(key string, contentType string, data []byte) error
+	GetBlob(key string) (io.ReadCloser, error)
+	DeleteBlob(key string) error
+}
+
+// ImageStore links stored images to what they picture, images nothing links to anymore are orphans to sweep
+type ImageStore interface {
+	GetAvatarByNeighborId(neighborId int) (*Images, error)
+	CreateAvatar(neighborId int, image Images) error
+	DeleteAvatar(neighborId int) (bool, error)
+	GetEventCoverByEventId(eventId int) (*Images, error)
+	CreateEventCover(eventId int, image Images) error
+	DeleteEventCover(eventId int) (bool, error)
+	GetOrphanedImages(limit int) ([]Images, error)
+	DeleteImage(id int) error
 }
 
 type NeighborhoodStore interface {
-	GetNeighborhoods() ([]Neighborhoods, error)
+	GetNeighborhoods(page Pagination) ([]Neighborhoods, error)
+	GetNeighborhoodById(id int) (*Neighborhoods, error)
 	CreateNeighborhood(Neighborhoods) error
+	GetModerators(neighborhoodId int) ([]NeighborhoodModerators, error)
+	CreateModerator(NeighborhoodModerators) error
+	DeleteModerator(neighborhoodId int, neighborId int) error
 }
 
 // TODO: need to add state abbreviations to table
@@ -85,6 +197,21 @@ type Neighborhoods struct {
 	CreatedAt    time.Time `json:"createdAt"`
 }
 
+type NeighborhoodPayload struct {
+	Neighborhood string `json:"neighborhood" validate:"required,max=255"`
+}
+
+type NeighborhoodModerators struct {
+	Id             int       `json:"id"`
+	NeighborhoodId int       `json:"neighborhoodId"`
+	NeighborId     int       `json:"neighborId"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+type ModeratorPayload struct {
+	NeighborId int `json:"neighborId" validate:"required"`
+}
+
 type Neighbors struct {
 	Id             int       `json:"id"`
 	Email          string    `json:"email"`
@@ -95,7 +222,66 @@ type Neighbors struct {
 	Ip             string    `json:"ip"`
 	NeighborhoodId int       `json:"neighborhoodId"`
 	CreatedAt      time.Time `json:"createdAt"`
-	// TODO: role
+	TokenVersion   int       `json:"-"`    // bumped to log the neighbor out everywhere
+	Role           string    `json:"role"` // admin or member, moderators are per neighborhood
+}
+
+// AccountDeletions are neighbors that asked to leave, they're purged once the grace period is over
+type AccountDeletions struct {
+	NeighborId  int       `json:"neighborId"`
+	RequestedAt time.Time `json:"requestedAt"`
+	PurgeAfter  time.Time `json:"purgeAfter"`
+}
+
+type DeleteAccountPayload struct {
+	Password string `json:"password" validate:"required"`
+}
+
+// NeighborExport is everything stored about a neighbor, friends and requests only carry the other neighbor's id
+type NeighborExport struct {
+	ExportedAt     time.Time              `json:"exportedAt"`
+	Neighbor       Neighbors              `json:"neighbor"`
+	Addresses      []Addresses            `json:"addresses"`
+	HostedEvents   []Events               `json:"hostedEvents"`
+	Rsvps          []EventRsvps           `json:"rsvps"`
+	EventInvites   []NeighborEventInvites `json:"eventInvites"`
+	Friends        []Friends              `json:"friends"`
+	FriendRequests []FriendRequests       `json:"friendRequests"`
+	Notifications  []Notifications        `json:"notifications"`
+	Logins         []NeighborLogins       `json:"logins"`
+	Profile        Profiles               `json:"profile"`
+	ProfileHistory []ProfileHistory       `json:"profileHistory"`
+	Blocks         []Blocks               `json:"blocks"`
+}
+
+// NeighborLogins is the login history, newest first the first row has the last login ip
+type NeighborLogins struct {
+	Id         int       `json:"id"`
+	NeighborId int       `json:"neighborId"`
+	Ip         string    `json:"ip"`
+	UserAgent  string    `json:"userAgent"`
+	LoggedInAt time.Time `json:"loggedInAt"`
+}
+
+type RolePayload struct {
+	Role string `json:"role" validate:"required,oneof=admin member"`
+}
+
+// Sessions are a device's login, the access token's jti changes every time the refresh token is used
+type Sessions struct {
+	Id                       int       `json:"id"`
+	NeighborId               int       `json:"neighborId"`
+	Jti                      string    `json:"-"`
+	RefreshTokenHash         string    `json:"-"`
+	PreviousRefreshTokenHash string    `json:"-"`
+	ExpiresAt                time.Time `json:"expiresAt"`
+	Revoked                  bool      `json:"revoked"`
+	CreatedAt                time.Time `json:"createdAt"`
+	LastUsedAt               time.Time `json:"lastUsedAt"`
+}
+
+type RefreshPayload struct {
+	RefreshToken string `json:"refreshToken" validate:"required"`
 }
 
 type Register struct {
@@ -111,6 +297,24 @@ type Login struct {
 }
 
 type UpdatePassword struct {
+	CurrentPassword string `json:"currentPassword" validate:"required"`
+	Password        string `json:"password" validate:"required,min=8"`
+}
+
+// fields left out aren't changed, the current password is needed to change the email or username
+type UpdateMePayload struct {
+	Email           *string `json:"email" validate:"omitempty,email,max=255"`
+	Username        *string `json:"username" validate:"omitempty,min=1,max=30"`
+	Zipcode         *string `json:"zipcode" validate:"omitempty,len=5"`
+	CurrentPassword string  `json:"currentPassword"`
+}
+
+type PasswordResetRequest struct {
+	EmailOrUsername string `json:"emailOrUsername" validate:"required"`
+}
+
+type PasswordResetConfirm struct {
+	Token    string `json:"token" validate:"required"`
 	Password string `json:"password" validate:"required,min=8"`
 }
 
@@ -141,22 +345,62 @@ type AddressPayload struct {
 }
 
 type Profiles struct {
-	NeighborId               int       `json:"id"`
-	Bio                      string    `json:"bio"`
-	DateOfBirth              time.Time `json:"dateOfBirth"`
-	DateOfBirthPublic        bool      `json:"dateOfBirthPublic"`
-	Gender                   string    `json:"gender"`
-	GenderPublic             bool      `json:"genderPublic"`
-	Race                     string    `json:"race"`
-	RacePublic               bool      `json:"racePublic"`
-	Ethnicity                string    `json:"ethnicity"`
-	EthnicityPublic          bool      `json:"ethnicityPublic"`
-	RelationshipStatus       string    `json:"relationshipStatus"`
-	RelationshipStatusPublic bool      `json:"relationshipStatusPublic"`
-	Religion                 string    `json:"religion"`
-	ReligionPublic           bool      `json:"religionPublic"`
-	Politics                 string    `json:"politics"`
-	PoliticsPublic           bool      `json:"politicsPublic"`
+	NeighborId                   int           `json:"id"`
+	Bio                          string        `json:"bio"`
+	DateOfBirth                  *time.Time    `json:"dateOfBirth"` // nil until the neighbor sets it
+	DateOfBirthVisibility        string        `json:"dateOfBirthVisibility"`
+	Gender                       string        `json:"gender"`
+	GenderVisibility             string        `json:"genderVisibility"`
+	Race                         string        `json:"race"`
+	RaceVisibility               string        `json:"raceVisibility"`
+	Ethnicity                    string        `json:"ethnicity"`
+	EthnicityVisibility          string        `json:"ethnicityVisibility"`
+	RelationshipStatus           string        `json:"relationshipStatus"`
+	RelationshipStatusVisibility string        `json:"relationshipStatusVisibility"`
+	Religion                     string        `json:"religion"`
+	ReligionVisibility           string        `json:"religionVisibility"`
+	Politics                     string        `json:"politics"`
+	PoliticsVisibility           string        `json:"politicsVisibility"`
+	Education                    []Education   `json:"education"`
+	Occupations                  []Occupations `json:"occupations"`
+	Interests                    []Interests   `json:"interests"`
+}
+
+// Images are re-encoded uploads, the keys are where the image and its thumbnail are in the BlobStore
+type Images struct {
+	Id           int       `json:"id"`
+	BlobKey      string    `json:"-"`
+	ThumbnailKey string    `json:"-"`
+	ContentType  string    `json:"contentType"`
+	Width        int       `json:"width"`
+	Height       int       `json:"height"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// ProfileHistory is one value an attribute had, attribute is named like its field in Profiles
+type ProfileHistory struct {
+	Attribute  string    `json:"attribute"`
+	Value      string    `json:"value"`
+	Visibility string    `json:"visibility"`
+	RecordedAt time.Time `json:"recordedAt"`
+}
+
+// ProfileField is one attribute and who besides its owner can see it, leaving visibility out keeps it private
+type ProfileField struct {
+	Value      string `json:"value" validate:"max=100"`
+	Visibility string `json:"visibility" validate:"omitempty,oneof=private friends neighborhood public"`
+}
+
+// ProfilePayload only touches the attributes that are sent, dateOfBirth's value is YYYY-MM-DD
+type ProfilePayload struct {
+	Bio                *string       `json:"bio" validate:"omitempty,max=1000"`
+	DateOfBirth        *ProfileField `json:"dateOfBirth"`
+	Gender             *ProfileField `json:"gender"`
+	Race               *ProfileField `json:"race"`
+	Ethnicity          *ProfileField `json:"ethnicity"`
+	RelationshipStatus *ProfileField `json:"relationshipStatus"`
+	Religion           *ProfileField `json:"religion"`
+	Politics           *ProfileField `json:"politics"`
 }
 
 type Bios struct {
@@ -170,7 +414,7 @@ type DatesOfBirth struct {
 	Id          int       `json:"id"`
 	NeighborId  int       `json:"neighborId"`
 	DateOfBirth time.Time `json:"dateOfBirth"`
-	Public      bool      `json:"public"`
+	Visibility  string    `json:"visibility"`
 	RecordedAt  time.Time `json:"recordedAt"`
 }
 
@@ -178,7 +422,7 @@ type Genders struct {
 	Id         int       `json:"id"`
 	NeighborId int       `json:"neighborId"`
 	Gender     string    `json:"gender"`
-	Public     bool      `json:"public"`
+	Visibility string    `json:"visibility"`
 	RecordedAt time.Time `json:"recordedAt"`
 }
 
@@ -186,7 +430,7 @@ type Races struct {
 	Id         int       `json:"id"`
 	NeighborId int       `json:"neighborId"`
 	Race       string    `json:"race"`
-	Public     bool      `json:"public"`
+	Visibility string    `json:"visibility"`
 	RecordedAt time.Time `json:"recordedAt"`
 }
 
@@ -194,7 +438,7 @@ type Ethnicities struct {
 	Id         int       `json:"id"`
 	NeighborId int       `json:"neighborId"`
 	Ethnicity  string    `json:"ethnicity"`
-	Public     bool      `json:"public"`
+	Visibility string    `json:"visibility"`
 	RecordedAt time.Time `json:"recordedAt"`
 }
 
@@ -202,7 +446,7 @@ type RelationshipStatuses struct {
 	Id                 int       `json:"id"`
 	NeighborId         int       `json:"neighborId"`
 	RelationshipStatus string    `json:"relationshipStatus"`
-	Public             bool      `json:"public"`
+	Visibility         string    `json:"visibility"`
 	RecordedAt         time.Time `json:"recordedAt"`
 }
 
@@ -210,7 +454,7 @@ type Religions struct {
 	Id         int       `json:"id"`
 	NeighborId int       `json:"neighborId"`
 	Religion   string    `json:"religion"`
-	Public     bool      `json:"public"`
+	Visibility string    `json:"visibility"`
 	RecordedAt time.Time `json:"recordedAt"`
 }
 
@@ -218,53 +462,95 @@ type Politics struct {
 	Id         int       `json:"id"`
 	NeighborId int       `json:"neighborId"`
 	Politics   string    `json:"politics"`
-	Public     bool      `json:"public"`
+	Visibility string    `json:"visibility"`
 	RecordedAt time.Time `json:"recordedAt"`
 }
 
+// Education and Occupations are listed newest first, a nil end means it's ongoing
 type Education struct {
-	Id         int       `json:"id"`
-	NeighborId int       `json:"neighborId"`
-	School     string    `json:"school"`
-	Degree     string    `json:"degree"`
-	Start      time.Time `json:"start"`
-	End        time.Time `json:"end"`
-	Public     bool      `json:"public"`
-	RecordedAt time.Time `json:"recordedAt"`
+	Id         int        `json:"id"`
+	NeighborId int        `json:"neighborId"`
+	School     string     `json:"school"`
+	Degree     string     `json:"degree"`
+	Start      time.Time  `json:"start"`
+	End        *time.Time `json:"end"`
+	Visibility string     `json:"visibility"`
+	RecordedAt time.Time  `json:"recordedAt"`
 }
 
 type Occupations struct {
-	Id         int       `json:"id"`
-	NeighborId int       `json:"neighborId"`
-	Role       string    `json:"role"`
-	Employer   string    `json:"employer"`
-	Start      time.Time `json:"start"`
-	End        time.Time `json:"end"`
-	Public     bool      `json:"public"`
-	RecordedAt time.Time `json:"recordedAt"`
+	Id         int        `json:"id"`
+	NeighborId int        `json:"neighborId"`
+	Role       string     `json:"role"`
+	Employer   string     `json:"employer"`
+	Start      time.Time  `json:"start"`
+	End        *time.Time `json:"end"`
+	Visibility string     `json:"visibility"`
+	RecordedAt time.Time  `json:"recordedAt"`
 }
 
+// Interests point into the taxonomy so the same interest matches across neighbors
 type Interests struct {
 	Id         int       `json:"id"`
 	NeighborId int       `json:"neighborId"`
+	InterestId int       `json:"interestId"`
 	Interest   string    `json:"interest"`
+	Category   string    `json:"category"`
+	Visibility string    `json:"visibility"`
 	RecordedAt time.Time `json:"recordedAt"`
 }
 
+type InterestTaxonomy struct {
+	Id       int    `json:"id"`
+	Interest string `json:"interest"`
+	Category string `json:"category"`
+}
+
+// dates are YYYY-MM-DD, leave end out while it's ongoing
+type EducationPayload struct {
+	School     string `json:"school" validate:"required,max=255"`
+	Degree     string `json:"degree" validate:"max=255"`
+	Start      string `json:"start" validate:"required,datetime=2006-01-02"`
+	End        string `json:"end" validate:"omitempty,datetime=2006-01-02"`
+	Visibility string `json:"visibility" validate:"omitempty,oneof=private friends neighborhood public"`
+}
+
+type OccupationPayload struct {
+	Role       string `json:"role" validate:"required,max=255"`
+	Employer   string `json:"employer" validate:"max=255"`
+	Start      string `json:"start" validate:"required,datetime=2006-01-02"`
+	End        string `json:"end" validate:"omitempty,datetime=2006-01-02"`
+	Visibility string `json:"visibility" validate:"omitempty,oneof=private friends neighborhood public"`
+}
+
+// adding an interest the neighbor already has only changes its visibility
+type InterestPayload struct {
+	InterestId int    `json:"interestId" validate:"required"`
+	Visibility string `json:"visibility" validate:"omitempty,oneof=private friends neighborhood public"`
+}
+
+type InterestTaxonomyPayload struct {
+	Interest string `json:"interest" validate:"required,max=100"`
+	Category string `json:"category" validate:"required,max=100"`
+}
+
 type Events struct {
-	Id             int       `json:"id"`
-	Name           string    `json:"name"`
-	Description    string    `json:"description"`
-	Start          time.Time `json:"start"`
-	End            time.Time `json:"end"`
-	Reoccurrence   string    `json:"reoccurrence"`
-	ForUnloggedins bool      `json:"forUnloggedins"`
-	ForUnverifieds bool      `json:"forUnverifieds"`
-	InviteOnly     bool      `json:"inviteOnly"`
-	HostId         int       `json:"hostId"`
-	AddressId      int       `json:"addressId"`
-	CreatedAt      time.Time `json:"createdAt"`
-	// add category
+	Id                 int       `json:"id"`
+	Name               string    `json:"name"`
+	Description        string    `json:"description"`
+	Start              time.Time `json:"start"`
+	End                time.Time `json:"end"`
+	Reoccurrence       string    `json:"reoccurrence"`
+	ForUnloggedins     bool      `json:"forUnloggedins"`
+	ForUnverifieds     bool      `json:"forUnverifieds"`
+	InviteOnly         bool      `json:"inviteOnly"`
+	HostId             int       `json:"hostId"`
+	AddressId          int       `json:"addressId"`
+	CreatedAt          time.Time `json:"createdAt"`
+	Capacity           int       `json:"capacity"` // 0 is unlimited
+	Status             string    `json:"status"`   // scheduled or cancelled
+	CancellationReason string    `json:"cancellationReason"`
+	Category           string    `json:"category"`
 }
 
 type CreateEventPayload struct {
@@ -283,62 +569,179 @@ type CreateEventPayload struct {
 	Type           string    `json:"type"`
 	HostId         int       `json:"hostId"`
 	AddressId      int       `json:"addressId"`
+	Capacity       int       `json:"capacity" validate:"min=0"`
+	Category       string    `json:"category" validate:"max=30"`
 }
 
-type LocationFilterPayload struct {
-	LocationFilter string `json:"locationFilter"`
+type UpdateEventPayload struct {
+	Name           string    `json:"name" validate:"required"`
+	Description    string    `json:"description"`
+	Start          time.Time `json:"start" validate:"required"`
+	End            time.Time `json:"end" validate:"required"`
+	Reoccurrence   string    `json:"reoccurrence"`
+	ForUnloggedins bool      `json:"forUnloggedins"`
+	ForUnverifieds bool      `json:"forUnverifieds"`
+	InviteOnly     bool      `json:"inviteOnly"`
+	Capacity       int       `json:"capacity" validate:"min=0"`
+	Category       string    `json:"category" validate:"max=30"`
+}
+
+type CancelEventPayload struct {
+	Status string `json:"status" validate:"required,eq=cancelled"`
+	Reason string `json:"reason" validate:"max=255"`
 }
 
-type DateFilterPayload struct {
-	DateFilter string    `json:"dateFilter"`
-	DateTime   time.Time `json:"dateTime"`
+// Pagination is a page of a list query, rows come after the cursor in the list's order
+type Pagination struct {
+	Limit int    // 0 is unlimited
+	After Cursor // the zero value starts from the beginning
 }
 
-type LocationDateFilterPayload struct {
-	LocationFilter string    `json:"locationFilter"`
-	DateFilter     string    `json:"dateFilter"`
-	DateTime       time.Time `json:"dateTime"`
+// Cursor is the sort key and id of the last row on a page
+type Cursor struct {
+	Key string `json:"key,omitempty"`
+	Id  int    `json:"id"`
 }
 
-type EventFilterPayload struct {
-	LocationFilter string    `json:"locationFilter"`
-	DateFilter     string    `json:"dateFilter"`
-	DateTime       time.Time `json:"dateTime"`
+// zero values aren't filtered on, repeating events are matched by their occurrences
+type EventQuery struct {
+	Zipcode        string
+	NeighborhoodId int
+	City           string
+	State          string
+	From           time.Time
+	FromInclusive  bool
+	To             time.Time
+	ToInclusive    bool
+	HostId         int
+	Category       string
+	Text           string
+	ViewerId       int  // invite only events are left out unless the viewer hosts or was invited
+	PublicOnly     bool // only events open to neighbors that aren't logged in
+	Unverified     bool // the viewer isn't verified, see the events visibility policy
+	Descending     bool
+	Limit          int
+	Cursor         *EventCursor
+	Series         bool // repeating events come back once with their rule instead of per occurrence
+}
+
+// position of the last event on a page, events are ordered by start then id
+type EventCursor struct {
+	Start time.Time `json:"start"`
+	Id    int       `json:"id"`
 }
 
 type EventAddresses struct {
-	Id               int       `json:"id"`
-	Name             string    `json:"name"`
-	Description      string    `json:"description"`
-	Start            time.Time `json:"start"`
-	End              time.Time `json:"end"`
-	Reoccurrence     string    `json:"reoccurrence"`
-	ForUnloggedins   bool      `json:"forUnloggedins"`
-	ForUnverifieds   bool      `json:"forUnverifieds"`
-	InviteOnly       bool      `json:"inviteOnly"`
-	HostId           int       `json:"hostId"`
-	AddressId        int       `json:"addressId"`
-	CreatedAt        time.Time `json:"createdAt"`
-	AddressAddressId int       `json:"addressAddressId"`
-	FirstName        string    `json:"firstName"`
-	LastName         string    `json:"lastName"`
-	Address          string    `json:"address"`
-	City             string    `json:"city"`
-	State            string    `json:"state"`
-	Zipcode          string    `json:"zipcode"`
-	Type             string    `json:"type"`
-	NeighborId       int       `json:"neighborId"`
-	NeighborhoodId   int       `json:"neighborhoodId"`
-	RecordedAt       time.Time `json:"recordedAt"`
+	Id                 int       `json:"id"`
+	Name               string    `json:"name"`
+	Description        string    `json:"description"`
+	Start              time.Time `json:"start"`
+	End                time.Time `json:"end"`
+	Reoccurrence       string    `json:"reoccurrence"`
+	ForUnloggedins     bool      `json:"forUnloggedins"`
+	ForUnverifieds     bool      `json:"forUnverifieds"`
+	InviteOnly         bool      `json:"inviteOnly"`
+	HostId             int       `json:"hostId"`
+	AddressId          int       `json:"addressId"`
+	CreatedAt          time.Time `json:"createdAt"`
+	Capacity           int       `json:"capacity"`
+	Status             string    `json:"status"`
+	CancellationReason string    `json:"cancellationReason"`
+	Category           string    `json:"category"`
+	AddressAddressId   int       `json:"addressAddressId"`
+	FirstName          string    `json:"firstName"`
+	LastName           string    `json:"lastName"`
+	Address            string    `json:"address"`
+	City               string    `json:"city"`
+	State              string    `json:"state"`
+	Zipcode            string    `json:"zipcode"`
+	Type               string    `json:"type"`
+	NeighborId         int       `json:"neighborId"`
+	NeighborhoodId     int       `json:"neighborhoodId"`
+	RecordedAt         time.Time `json:"recordedAt"`
+}
+
+type EventRsvps struct {
+	Id          int       `json:"id"`
+	EventId     int       `json:"eventId"`
+	NeighborId  int       `json:"neighborId"`
+	Status      string    `json:"status"` // going, maybe, declined or waitlisted
+	RespondedAt time.Time `json:"respondedAt"`
+}
+
+type RsvpPayload struct {
+	Status string `json:"status" validate:"required,oneof=going maybe declined"`
+}
+
+type EventAttendees struct {
+	RsvpId      int       `json:"rsvpId"`
+	NeighborId  int       `json:"neighborId"`
+	Username    string    `json:"username"`
+	Status      string    `json:"status"`
+	RespondedAt time.Time `json:"respondedAt"`
 }
 
 type EventInvites struct {
 	Id                int       `json:"id"`
 	EventId           int       `json:"eventId"`
 	InvitedNeighborId int       `json:"invitedNeighborId"`
+	Status            string    `json:"status"`
 	InvitedAt         time.Time `json:"invitedAt"`
 }
 
+type EventInvitePayload struct {
+	NeighborIds []int    `json:"neighborIds"`
+	Usernames   []string `json:"usernames"`
+}
+
+type NeighborEventInvites struct {
+	InviteId     int       `json:"inviteId"`
+	Status       string    `json:"status"`
+	InvitedAt    time.Time `json:"invitedAt"`
+	EventId      int       `json:"eventId"`
+	Name         string    `json:"name"`
+	Description  string    `json:"description"`
+	Start        time.Time `json:"start"`
+	End          time.Time `json:"end"`
+	HostId       int       `json:"hostId"`
+	HostUsername string    `json:"hostUsername"`
+}
+
+type CalendarFeeds struct {
+	Id         int       `json:"id"`
+	NeighborId int       `json:"neighborId"`
+	TokenHash  string    `json:"-"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+type NeighborTokens struct {
+	Id         int       `json:"id"`
+	NeighborId int       `json:"neighborId"`
+	Purpose    string    `json:"purpose"` // verify, reset or unlock
+	TokenHash  string    `json:"-"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	Used       bool      `json:"used"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// TwoFactors is a neighbor's totp secret, it only applies to logins once a code has confirmed it
+type TwoFactors struct {
+	NeighborId   int       `json:"neighborId"`
+	Secret       string    `json:"-"`
+	Enabled      bool      `json:"enabled"`
+	LastUsedStep int64     `json:"-"` // codes can't be used twice
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+type TwoFactorCodePayload struct {
+	Code string `json:"code" validate:"required,max=32"`
+}
+
+type TwoFactorLoginPayload struct {
+	Token string `json:"token" validate:"required"`
+	Code  string `json:"code" validate:"required,max=32"` // totp or recovery code
+}
+
 type Friends struct {
 	Id                int       `json:"id"`
 	NeighborId        int       `json:"neighborId"`
@@ -346,33 +749,32 @@ type Friends struct {
 	FriendedAt        time.Time `json:"friendedAt"`
 }
 
+// FriendsList is the other neighbor in a friendship, anything more personal is on their profile
 type FriendsList struct {
-	Id                     int       `json:"id"`
-	NeighborId             int       `json:"neighborId"`
-	NeighborsFriendId      int       `json:"neighborsFriendId"`
-	FriendedAt             time.Time `json:"friendedAt"`
-	NeighborsId            int       `json:"neighborsId"`
-	Email                  string    `json:"email"`
-	Username               string    `json:"username"`
-	NeighborZipcode        string    `json:"neighborZipcode"`
-	Password               string    `json:"-"`
-	Verified               bool      `json:"verified"`
-	Ip                     string    `json:"ip"`
-	NeighborNeighborhoodId int       `json:"neighborNeighborhoodId"`
-	CreatedAt              time.Time `json:"createdAt"`
-	AddressesId            int       `json:"addressesId"`
-	FirstName              string    `json:"firstName"`
-	LastName               string    `json:"lastName"`
-	Address                string    `json:"address"`
-	City                   string    `json:"city"`
-	State                  string    `json:"state"`
-	Zipcode                string    `json:"zipcode"`
-	Type                   string    `json:"type"`
-	AddressesNeighborId    int       `json:"addressesNeighborId"`
-	NeighborhoodId         int       `json:"neighborhoodId"`
-	RecordedAt             time.Time `json:"recordedAt"`
+	Id         int       `json:"id"`
+	FriendId   int       `json:"friendId"`
+	Username   string    `json:"username"`
+	FirstName  string    `json:"firstName"`
+	LastName   string    `json:"lastName"`
+	FriendedAt time.Time `json:"friendedAt"`
 }
 
+// Blocks hide NeighborId from BlockedNeighborId, blocking ends their friendship and any requests between them
+type Blocks struct {
+	Id                int       `json:"id"`
+	NeighborId        int       `json:"neighborId"`
+	BlockedNeighborId int       `json:"blockedNeighborId"`
+	CreatedAt         time.Time `json:"createdAt"`
+}
+
+// what asking a neighbor to be friends ended up doing
+const (
+	RequestPending  = "pending"
+	RequestAccepted = "accepted"
+	AlreadyPending  = "already pending"
+	AlreadyFriends  = "already friends"
+)
+
 type FriendRequests struct {
 	Id                int       `json:"id"`
 	NeighborId        int       `json:"neighborId"`
@@ -381,21 +783,41 @@ type FriendRequests struct {
 	FriendRequestedAt time.Time `json:"friendRequestedAt"`
 }
 
+// PendingFriendRequests carry who sent them, anything more personal is on their profile
 type PendingFriendRequests struct {
 	FriendRequestId   int       `json:"friendRequestId"`
 	NeighborId        int       `json:"neighborId"`
 	RequestedFriendId int       `json:"requestedFriendId"`
 	Status            string    `json:"status"`
 	FriendRequestedAt time.Time `json:"friendRequestedAt"`
-	Id                int       `json:"id"`
-	Email             string    `json:"email"`
 	Username          string    `json:"username"`
-	Zipcode           string    `json:"zipcode"`
-	Password          string    `json:"-"`
-	Verified          bool      `json:"verified"`
-	Ip                string    `json:"ip"`
-	NeighborhoodId    int       `json:"neighborhoodId"`
-	CreatedAt         time.Time `json:"createdAt"`
 }
 
-// TODO: notifications table
+// FriendSuggestions are neighbors who aren't friends yet, ranked by score with what they have in common
+type FriendSuggestions struct {
+	NeighborId       int      `json:"neighborId"`
+	Username         string   `json:"username"`
+	MutualFriends    int      `json:"mutualFriends"`
+	SameNeighborhood bool     `json:"sameNeighborhood"`
+	SameZipcode      bool     `json:"sameZipcode"`
+	SharedEvents     int      `json:"sharedEvents"`
+	SharedInterests  int      `json:"sharedInterests"`
+	Score            int      `json:"score"`
+	Reasons          []string `json:"reasons"`
+}
+
+// SuggestionCursor pages suggestions by score, highest first
+type SuggestionCursor struct {
+	Score int `json:"score"`
+	Id    int `json:"id"`
+}
+
+type Notifications struct {
+	Id         int       `json:"id"`
+	NeighborId int       `json:"neighborId"`
+	EventId    int       `json:"eventId"`
+	Type       string    `json:"type"`
+	Message    string    `json:"message"`
+	Read       bool      `json:"read"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
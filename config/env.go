@@ -1,15 +1,41 @@
 package config
 
 import (
+	"log"
+	"net/netip"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	JWTExpirationInSeconds int64
-	JWTSecret              string
+	PublicURL                         string
+	JWTExpirationInSeconds            int64
+	JWTSecret                         string
+	RefreshTokenExpirationInSeconds   int64
+	VerifyTokenExpirationInSeconds    int64
+	ResetTokenExpirationInSeconds     int64
+	ClientURL                         string
+	SMTPHost                          string
+	SMTPPort                          int64
+	SMTPUsername                      string
+	SMTPPassword                      string
+	MailFrom                          string
+	MailDir                           string
+	LoginAttemptStore                 string
+	TwoFactorTokenExpirationInSeconds int64
+	TrustedProxies                    []netip.Prefix
+	AccountDeletionGraceInSeconds     int64
+	BlobStore                         string
+	BlobDir                           string
+	S3Endpoint                        string
+	S3Region                          string
+	S3Bucket                          string
+	S3AccessKeyId                     string
+	S3SecretAccessKey                 string
+	MaxUploadSizeInBytes              int64
 }
 
 var Envs = initConfig()
@@ -18,8 +44,31 @@ func initConfig() Config {
 	godotenv.Load()
 
 	return Config{
-		JWTSecret:              getEnv("JWT_SECRET", "not-secret-secret-anymore?"),
-		JWTExpirationInSeconds: getEnvAsInt("JWT_EXP", 3600*24*7),
+		PublicURL:                         getEnv("PUBLIC_URL", "http://localhost:8080"),
+		JWTSecret:                         getEnv("JWT_SECRET", "not-secret-secret-anymore?"),
+		JWTExpirationInSeconds:            getEnvAsInt("JWT_EXP", 60*15),
+		RefreshTokenExpirationInSeconds:   getEnvAsInt("REFRESH_TOKEN_EXP", 3600*24*30),
+		VerifyTokenExpirationInSeconds:    getEnvAsInt("VERIFY_TOKEN_EXP", 3600*24),
+		ResetTokenExpirationInSeconds:     getEnvAsInt("RESET_TOKEN_EXP", 3600),
+		ClientURL:                         getEnv("CLIENT_URL", "http://localhost:3000"),
+		SMTPHost:                          getEnv("SMTP_HOST", ""),
+		SMTPPort:                          getEnvAsInt("SMTP_PORT", 587),
+		SMTPUsername:                      getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:                      getEnv("SMTP_PASSWORD", ""),
+		MailFrom:                          getEnv("MAIL_FROM", "Neighborhost <no-reply@neighborhost.com>"),
+		MailDir:                           getEnv("MAIL_DIR", ""),
+		LoginAttemptStore:                 getEnv("LOGIN_ATTEMPT_STORE", "postgres"),
+		TwoFactorTokenExpirationInSeconds: getEnvAsInt("TWO_FACTOR_TOKEN_EXP", 60*5),
+		TrustedProxies:                    getEnvAsPrefixes("TRUSTED_PROXIES"),
+		AccountDeletionGraceInSeconds:     getEnvAsInt("ACCOUNT_DELETION_GRACE", 3600*24*30),
+		BlobStore:                         getEnv("BLOB_STORE", "local"),
+		BlobDir:                           getEnv("BLOB_DIR", "uploads"),
+		S3Endpoint:                        getEnv("S3_ENDPOINT", ""),
+		S3Region:                          getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:                          getEnv("S3_BUCKET", ""),
+		S3AccessKeyId:                     getEnv("S3_ACCESS_KEY_ID", ""),
+		S3SecretAccessKey:                 getEnv("S3_SECRET_ACCESS_KEY", ""),
+		MaxUploadSizeInBytes:              getEnvAsInt("MAX_UPLOAD_SIZE", 10<<20),
 	}
 }
 
@@ -43,3 +92,24 @@ func getEnvAsInt(key string, fallback int64) int64 {
 
 	return fallback
 }
+
+// comma separated addresses or CIDR ranges, entries that don't parse are skipped
+func getEnvAsPrefixes(key string) []netip.Prefix {
+	prefixes := make([]netip.Prefix, 0)
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		if prefix, err := netip.ParsePrefix(value); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+		} else if ip, err := netip.ParseAddr(value); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(ip, ip.BitLen()))
+		} else {
+			log.Printf("skipping %s entry %q: not an address or CIDR range", key, value)
+		}
+	}
+
+	return prefixes
+}
@@ -109,11 +109,13 @@ func (s *Store) GetAddressByNeighborId(id int) (*types.Addresses, error) {
 	return addresses, nil
 }
 
-func (s *Store) GetAddressesByNeighborId(id int) ([]types.Addresses, error) {
+func (s *Store) GetAddressesByNeighborId(id int, page types.Pagination) ([]types.Addresses, error) {
 	rows, err := s.db.Query(
 		`SELECT * FROM addresses
 		WHERE neighbor_id = $1
-		ORDER BY address`, id,
+		AND (address, id) > ($2, $3)
+		ORDER BY address, id
+		LIMIT NULLIF($4, 0)`, id, page.After.Key, page.After.Id, page.Limit,
 	)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,225 @@
+package blobs
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Store works with any S3 compatible service using path style urls, e.g. a local MinIO for development
+type S3Store struct {
+	endpoint        *url.URL
+	region          string
+	bucket          string
+	accessKeyId     string
+	secretAccessKey string
+	client          *http.Client
+}
+
+func NewS3Store(endpoint string, region string, bucket string, accessKeyId string, secretAccessKey string) (*S3Store, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme == "" || u.Host == "" || bucket == "" {
+		return nil, fmt.Errorf("s3 needs an endpoint like https://host:port and a bucket")
+	}
+
+	return &S3Store{
+		endpoint:        u,
+		region:          region,
+		bucket:          bucket,
+		accessKeyId:     accessKeyId,
+		secretAccessKey: secretAccessKey,
+		client:          &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+func (s *S3Store) PutBlob(key string, contentType string, data []byte) error {
+	req, err := s.newRequest(http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return responseError(res)
+	}
+
+	return nil
+}
+
+// a missing blob comes back as an error wrapping fs.ErrNotExist
+func (s *S3Store) GetBlob(key string) (io.ReadCloser, error) {
+	req, err := s.newRequest(http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == http.StatusNotFound {
+		res.Body.Close()
+		return nil, fmt.Errorf("blob %s: %w", key, fs.ErrNotExist)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		return nil, responseError(res)
+	}
+
+	return res.Body, nil
+}
+
+// s3 answers 204 whether or not the key was there
+func (s *S3Store) DeleteBlob(key string) error {
+	req, err := s.newRequest(http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK && res.StatusCode != http.StatusNotFound {
+		return responseError(res)
+	}
+
+	return nil
+}
+
+func (s *S3Store) newRequest(method string, key string, body []byte) (*http.Request, error) {
+	path := strings.TrimSuffix(s.endpoint.Path, "/") + "/" + s.bucket + "/" + key
+
+	u := *s.endpoint
+	u.Path = path
+	u.RawPath = escapePath(path)
+
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	s.sign(req, body, time.Now().UTC())
+
+	return req, nil
+}
+
+// sign adds an AWS signature version 4 authorization header, covering the host, date, query and payload hash
+func (s *S3Store) sign(req *http.Request, body []byte, now time.Time) {
+	payloadHash := sha256Hex(body)
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretAccessKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKeyId, scope, signedHeaders, signature,
+	))
+}
+
+// escapePath percent-encodes everything but unreserved characters and the slashes between segments, like s3 expects
+func escapePath(path string) string {
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if c == '/' || c == '-' || c == '.' || c == '_' || c == '~' ||
+			('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String()
+}
+
+// the query parameters sorted by name then value, a parameter without a value still gets its "="
+func canonicalQuery(values url.Values) string {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(values))
+	for _, name := range names {
+		vals := append([]string{}, values[name]...)
+		sort.Strings(vals)
+		for _, val := range vals {
+			parts = append(parts, escapeQuery(name)+"="+escapeQuery(val))
+		}
+	}
+
+	return strings.Join(parts, "&")
+}
+
+// query names and values are escaped like paths, slashes included
+func escapeQuery(value string) string {
+	return strings.ReplaceAll(escapePath(value), "/", "%2F")
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+
+	return mac.Sum(nil)
+}
+
+func responseError(res *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+
+	return fmt.Errorf("s3 %s %s: %s %s", res.Request.Method, res.Request.URL.Path, res.Status, strings.TrimSpace(string(body)))
+}
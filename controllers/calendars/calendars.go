@@ -0,0 +1,66 @@
+package calendars
+
+import (
+	"database/sql"
+
+	"github.com/jamesdavidyu/neighborhost-service/cmd/model/types"
+	"github.com/jamesdavidyu/neighborhost-service/utils"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// each neighbor has one feed, creating it again rotates the token
+func (s *Store) UpsertCalendarFeed(feed types.CalendarFeeds) error {
+	_, err := s.db.Exec(
+		`INSERT INTO calendar_feeds (neighbor_id, token_hash)
+		VALUES ($1, $2)
+		ON CONFLICT (neighbor_id) DO UPDATE
+		SET token_hash = EXCLUDED.token_hash,
+		created_at = CURRENT_TIMESTAMP`,
+		feed.NeighborId,
+		feed.TokenHash,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) GetCalendarFeedByTokenHash(tokenHash string) (*types.CalendarFeeds, error) {
+	rows, err := s.db.Query(
+		`SELECT * FROM calendar_feeds
+		WHERE token_hash = $1`, tokenHash,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	feed := new(types.CalendarFeeds)
+	for rows.Next() {
+		feed, err = utils.ScanRowIntoCalendarFeeds(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return feed, nil
+}
+
+func (s *Store) DeleteCalendarFeed(neighborId int) error {
+	_, err := s.db.Exec(
+		`DELETE FROM calendar_feeds
+		WHERE neighbor_id = $1`, neighborId,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -1,18 +1,24 @@
 /*
 1. PUBLIC
-2. ZIPCODE
-3. NEIGHBORHOOD
-4. CITY
-5. GENERAL
+2. QUERY
+3. GENERAL
+4. RSVPS
+5. INVITES
+6. CALENDAR
 */
 
 package events
 
 import (
 	"database/sql"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/jamesdavidyu/neighborhost-service/cmd/model/types"
+	"github.com/jamesdavidyu/neighborhost-service/services/recurrence"
 	"github.com/jamesdavidyu/neighborhost-service/utils"
 )
 
@@ -24,15 +30,26 @@ func NewStore(db *sql.DB) *Store {
 	return &Store{db: db}
 }
 
+// how far past the requested date repeating events are expanded
+const recurrenceHorizon = 90 * 24 * time.Hour
+
 /* 1. PUBLIC */
 
-func (s *Store) GetPublicEvents() ([]types.Events, error) {
+func (s *Store) GetPublicEvents(limit int, cursor *types.EventCursor) ([]types.Events, error) {
+	now := time.Now()
+	after := types.EventCursor{Start: now}
+	if cursor != nil {
+		after = *cursor
+	}
+
 	rows, err := s.db.Query(
 		`SELECT * FROM events
 		WHERE for_unloggedins = TRUE
+		AND invite_only = FALSE
 		AND start >= $1
-		ORDER BY start
-		LIMIT 10`, time.Now(), // AB test to figure out optimal limit number
+		AND (start, id) > ($2, $3)
+		ORDER BY start, id
+		LIMIT NULLIF($4, 0)`, now, after.Start, after.Id, limit,
 	)
 	if err != nil {
 		return nil, err
@@ -50,16 +67,133 @@ func (s *Store) GetPublicEvents() ([]types.Events, error) {
 	return events, nil
 }
 
-/* 2. ZIPCODE */
+/* 2. QUERY */
+
+// single events are filtered and paged in sql, repeating events are expanded here and merged in
+func (s *Store) QueryEvents(query types.EventQuery) ([]types.EventAddresses, error) {
+	args := make([]any, 0)
+	arg := func(value any) string {
+		args = append(args, value)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	conditions := make([]string, 0)
+	if query.Zipcode != "" {
+		conditions = append(conditions, "a.zipcode = "+arg(query.Zipcode))
+	}
+
+	if query.NeighborhoodId != 0 {
+		conditions = append(conditions, "a.neighborhood_id = "+arg(query.NeighborhoodId))
+	}
+
+	if query.City != "" {
+		conditions = append(conditions, "a.city = "+arg(query.City))
+	}
+
+	if query.State != "" {
+		conditions = append(conditions, "a.state = "+arg(query.State))
+	}
+
+	if query.HostId != 0 {
+		conditions = append(conditions, "e.host_id = "+arg(query.HostId))
+	}
+
+	if query.Category != "" {
+		conditions = append(conditions, "e.category = "+arg(query.Category))
+	}
+
+	if query.Text != "" {
+		text := arg("%" + escapeLike(query.Text) + "%")
+		conditions = append(conditions, "(e.name ILIKE "+text+" OR e.description ILIKE "+text+")")
+	}
+
+	if query.PublicOnly {
+		conditions = append(conditions, "e.for_unloggedins = TRUE", "e.invite_only = FALSE")
+	} else {
+		viewer := arg(query.ViewerId)
+		conditions = append(conditions, `(e.invite_only = FALSE
+			OR e.host_id = `+viewer+`
+			OR EXISTS (
+				SELECT 1 FROM event_invites i
+				WHERE i.event_id = e.id
+				AND i.invited_neighbor_id = `+viewer+`
+				AND i.status <> 'declined'
+			))`, `NOT EXISTS (
+				SELECT 1 FROM blocks b
+				WHERE b.neighbor_id = e.host_id
+				AND b.blocked_neighbor_id = `+viewer+`
+			)`)
+
+		// unverified neighbors still see their friends' events and the ones they were invited to
+		if query.Unverified {
+			conditions = append(conditions, `(e.for_unverifieds = TRUE
+				OR e.for_unloggedins = TRUE
+				OR e.host_id = `+viewer+`
+				OR EXISTS (
+					SELECT 1 FROM event_invites i
+					WHERE i.event_id = e.id
+					AND i.invited_neighbor_id = `+viewer+`
+					AND i.status <> 'declined'
+				)
+				OR EXISTS (
+					SELECT 1 FROM friends f
+					WHERE (f.neighbor_id = `+viewer+` AND f.neighbors_friend_id = e.host_id)
+					OR (f.neighbor_id = e.host_id AND f.neighbors_friend_id = `+viewer+`)
+				))`)
+		}
+	}
+
+	from, to := wallClock(query.From), wallClock(query.To)
+
+	singles := append([]string{"e.reoccurrence NOT LIKE 'FREQ=%'"}, conditions...)
+	series := append([]string{"e.reoccurrence LIKE 'FREQ=%'"}, conditions...)
+
+	if !query.From.IsZero() {
+		operator := " > "
+		if query.FromInclusive {
+			operator = " >= "
+		}
+		singles = append(singles, "e.start"+operator+arg(from))
+	}
+
+	// a series starting after the range can't have an occurrence in it
+	if !query.To.IsZero() {
+		operator := " < "
+		if query.ToInclusive {
+			operator = " <= "
+		}
+		end := arg(to)
+		singles = append(singles, "e.start"+operator+end)
+		series = append(series, "e.start"+operator+end)
+	}
+
+	order := "ORDER BY e.start, e.id"
+	if query.Cursor != nil {
+		operator := " > "
+		if query.Descending {
+			operator = " < "
+		}
+		singles = append(singles, "(e.start, e.id)"+operator+"("+arg(query.Cursor.Start)+", "+arg(query.Cursor.Id)+")")
+	}
+
+	if query.Descending {
+		order = "ORDER BY e.start DESC, e.id DESC"
+	}
+
+	if query.Limit > 0 {
+		order += " LIMIT " + arg(query.Limit)
+	}
 
-func (s *Store) GetEventsByZipcode(zipcode string, dateTime time.Time) ([]types.EventAddresses, error) {
 	rows, err := s.db.Query(
-		`SELECT * FROM events e
+		`(SELECT * FROM events e
+		LEFT OUTER JOIN addresses a ON a.id = e.address_id
+		WHERE `+strings.Join(singles, "\n\t\tAND ")+`
+		`+order+`)
+		UNION ALL
+		(SELECT * FROM events e
 		LEFT OUTER JOIN addresses a ON a.id = e.address_id
-		WHERE a.zipcode = $1
-		AND start >= $2
-		ORDER BY start`, zipcode, dateTime,
-	) // need to add unloggedin, unverified, inviteOnly filters
+		WHERE `+strings.Join(series, "\n\t\tAND ")+`)`, args...,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -73,100 +207,290 @@ func (s *Store) GetEventsByZipcode(zipcode string, dateTime time.Time) ([]types.
 		events = append(events, *event)
 	}
 
+	if query.Series {
+		sortEvents(events, query.Descending)
+	} else {
+		// without a date range repeating events are expanded from now
+		switch {
+		case query.From.IsZero() && query.To.IsZero():
+			from = wallClock(time.Now())
+			to = from.Add(recurrenceHorizon)
+		case query.From.IsZero():
+			from = to.Add(-recurrenceHorizon)
+		case query.To.IsZero():
+			to = from.Add(recurrenceHorizon)
+		}
+
+		if !query.From.IsZero() && !query.FromInclusive {
+			from = from.Add(time.Microsecond)
+		}
+
+		if !query.To.IsZero() && query.ToInclusive {
+			to = to.Add(time.Microsecond)
+		}
+
+		events = expandOccurrences(events, from, to, query.Descending)
+	}
+
+	if query.Cursor != nil {
+		events = afterCursor(events, *query.Cursor, query.Descending)
+	}
+
+	if query.Limit > 0 && len(events) > query.Limit {
+		events = events[:query.Limit]
+	}
+
 	return events, nil
 }
 
-func (s *Store) GetZipcodeEventsOnDate(zipcode string, dateTime time.Time) ([]types.EventAddresses, error) {
-	rows, err := s.db.Query(
-		`SELECT * FROM events e
-		LEFT OUTER JOIN addresses a ON a.id = e.address_id
-		WHERE a.zipcode = $1
-		AND start = $2
-		ORDER BY start`, zipcode, dateTime,
+// keeps the events that come after the cursor in the query's order
+func afterCursor(events []types.EventAddresses, cursor types.EventCursor, descending bool) []types.EventAddresses {
+	kept := make([]types.EventAddresses, 0, len(events))
+	for _, event := range events {
+		after := event.Start.After(cursor.Start) || (event.Start.Equal(cursor.Start) && event.Id > cursor.Id)
+		before := event.Start.Before(cursor.Start) || (event.Start.Equal(cursor.Start) && event.Id < cursor.Id)
+		if (!descending && after) || (descending && before) {
+			kept = append(kept, event)
+		}
+	}
+
+	return kept
+}
+
+// the text is matched literally, not as a LIKE pattern
+func escapeLike(text string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(text)
+}
+
+/* ALL, needed? */
+
+// func (s *Store) GetAllEvents(dateTime time.Time) ([]types.EventAddresses, error) {
+// 	rows, err := s.db.Query(
+// 		`SELECT * FROM events e
+// 		LEFT OUTER JOIN addresses a ON a.id = e.address_id
+// 		WHERE start >= $1`, dateTime,
+// 	)
+// 	if err != nil {
+// 		return nil, err
+// 	}
+
+// 	events := make([]types.EventAddresses, 0)
+// 	for rows.Next() {
+// 		event, err := utils.ScanRowIntoNeighborEvents(rows)
+// 		if err != nil {
+// 			return nil, err
+// 		}
+// 		events = append(events, *event)
+// 	}
+
+// 	return events, nil
+// }
+
+/* 3. GENERAL */
+
+func (s *Store) CreateEvent(event types.Events) error {
+	_, err := s.db.Exec(
+		`INSERT INTO events (
+			name,
+			description,
+			start,
+			"end",
+			reoccurrence,
+			for_unloggedins,
+			for_unverifieds,
+			invite_only,
+			host_id,
+			address_id,
+			capacity,
+			category
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		event.Name,
+		event.Description,
+		event.Start,
+		event.End,
+		event.Reoccurrence,
+		event.ForUnloggedins,
+		event.ForUnverifieds,
+		event.InviteOnly,
+		event.HostId,
+		event.AddressId,
+		event.Capacity,
+		event.Category,
 	)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	events := make([]types.EventAddresses, 0)
-	for rows.Next() {
-		event, err := utils.ScanRowIntoNeighborEvents(rows)
-		if err != nil {
-			return nil, err
-		}
-		events = append(events, *event)
+	return nil
+}
+
+// raising the capacity lets waitlisted neighbors in, lowering it keeps everyone already going
+func (s *Store) UpdateEvent(event types.Events) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		`UPDATE events
+		SET name = $1,
+		description = $2,
+		start = $3,
+		"end" = $4,
+		reoccurrence = $5,
+		for_unloggedins = $6,
+		for_unverifieds = $7,
+		invite_only = $8,
+		capacity = $9,
+		category = $10
+		WHERE id = $11`,
+		event.Name,
+		event.Description,
+		event.Start,
+		event.End,
+		event.Reoccurrence,
+		event.ForUnloggedins,
+		event.ForUnverifieds,
+		event.InviteOnly,
+		event.Capacity,
+		event.Category,
+		event.Id,
+	)
+	if err != nil {
+		return err
 	}
 
-	return events, nil
+	if err := promoteWaitlisted(tx, event.Id, event.Capacity); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-func (s *Store) GetZipcodeEventsBeforeDate(zipcode string, dateTime time.Time) ([]types.EventAddresses, error) {
-	rows, err := s.db.Query(
-		`SELECT * FROM events e
-		LEFT OUTER JOIN addresses a ON a.id = e.address_id
-		WHERE a.zipcode = $1
-		AND start < $2
-		ORDER BY start DESC`, zipcode, dateTime,
+// cancelled events stay listed so neighbors can see what happened
+func (s *Store) CancelEvent(event types.Events) error {
+	_, err := s.db.Exec(
+		`UPDATE events
+		SET status = 'cancelled',
+		cancellation_reason = $1
+		WHERE id = $2`,
+		event.CancellationReason,
+		event.Id,
 	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// rsvps and invites are removed with the event
+func (s *Store) DeleteEvent(id int) error {
+	_, err := s.db.Exec("DELETE FROM events WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) GetEventById(id int) (*types.Events, error) {
+	rows, err := s.db.Query("SELECT * FROM events WHERE id = $1", id)
 	if err != nil {
 		return nil, err
 	}
 
-	events := make([]types.EventAddresses, 0)
+	event := new(types.Events)
 	for rows.Next() {
-		event, err := utils.ScanRowIntoNeighborEvents(rows)
+		event, err = utils.ScanRowIntoPublicEvents(rows)
 		if err != nil {
 			return nil, err
 		}
-		events = append(events, *event)
 	}
 
-	return events, nil
+	return event, nil
 }
 
-func (s *Store) GetZipcodeEventsAfterDate(zipcode string, dateTime time.Time) ([]types.EventAddresses, error) {
-	rows, err := s.db.Query(
-		`SELECT * FROM events e
-		LEFT OUTER JOIN addresses a ON a.id = e.address_id
-		WHERE a.zipcode = $1
-		AND start > $2
-		ORDER BY start`, zipcode, dateTime,
-	)
+// the neighborhood of the event's address, 0 when the event doesn't exist
+func (s *Store) GetEventNeighborhoodId(id int) (int, error) {
+	var neighborhoodId int
+	err := s.db.QueryRow(
+		`SELECT a.neighborhood_id FROM events e
+		JOIN addresses a ON a.id = e.address_id
+		WHERE e.id = $1`, id,
+	).Scan(&neighborhoodId)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
 
-	events := make([]types.EventAddresses, 0)
-	for rows.Next() {
-		event, err := utils.ScanRowIntoNeighborEvents(rows)
-		if err != nil {
-			return nil, err
+	return neighborhoodId, nil
+}
+
+// repeating events are replaced by their occurrences in [from, to), non repeating events are already filtered by the query
+func expandOccurrences(events []types.EventAddresses, from time.Time, to time.Time, descending bool) []types.EventAddresses {
+	expanded := make([]types.EventAddresses, 0, len(events))
+	for _, event := range events {
+		rule, err := recurrence.Parse(event.Reoccurrence)
+		if err != nil || rule == nil {
+			expanded = append(expanded, event)
+			continue
+		}
+
+		duration := event.End.Sub(event.Start)
+		for _, start := range rule.Between(event.Start, from, to) {
+			occurrence := event
+			occurrence.Start = start
+			occurrence.End = start.Add(duration)
+			expanded = append(expanded, occurrence)
 		}
-		events = append(events, *event)
 	}
 
-	return events, nil
+	sortEvents(expanded, descending)
+
+	return expanded
 }
 
-// need to add controllers for on/before and on/after
+// ties on start are broken by id so cursors have a stable order to page through
+func sortEvents(events []types.EventAddresses, descending bool) {
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].Start.Equal(events[j].Start) {
+			if descending {
+				return events[i].Start.After(events[j].Start)
+			}
+			return events[i].Start.Before(events[j].Start)
+		}
 
-/* 3. NEIGHBORHOOD */
+		if descending {
+			return events[i].Id > events[j].Id
+		}
+		return events[i].Id < events[j].Id
+	})
+}
+
+// events are stored as wall clock times, so windows in the neighbor's timezone are compared the same way
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
 
-func (s *Store) GetEventsByNeighborhoodId(neighborhoodId int, dateTime time.Time) ([]types.EventAddresses, error) {
+// every event the neighbor hosts, past and future, for exporting their data
+func (s *Store) GetEventsByHostId(hostId int) ([]types.Events, error) {
 	rows, err := s.db.Query(
-		`SELECT * FROM events e
-		LEFT OUTER JOIN addresses a ON a.id = e.address_id
-		WHERE a.neighborhood_id = $1
-		AND start >= $2
-		ORDER BY start`, neighborhoodId, dateTime,
+		`SELECT * FROM events
+		WHERE host_id = $1
+		ORDER BY start, id`, hostId,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	events := make([]types.EventAddresses, 0)
+	events := make([]types.Events, 0)
 	for rows.Next() {
-		event, err := utils.ScanRowIntoNeighborEvents(rows)
+		event, err := utils.ScanRowIntoPublicEvents(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -176,227 +500,306 @@ func (s *Store) GetEventsByNeighborhoodId(neighborhoodId int, dateTime time.Time
 	return events, nil
 }
 
-func (s *Store) GetNeighborhoodEventsOnDate(neighborhoodId int, dateTime time.Time) ([]types.EventAddresses, error) {
-	rows, err := s.db.Query(
-		`SELECT * FROM events e 
-		LEFT OUTER JOIN addresses a ON a.id = e.address_id
-		WHERE a.neighborhood_id = $1
-		AND start = $2
-		ORDER BY start`, neighborhoodId, dateTime,
-	)
+/* 4. RSVPS */
+
+// going rsvps past the event's capacity are stored as waitlisted and promoted in the order they joined the waitlist
+func (s *Store) RespondToEvent(rsvp types.EventRsvps) (*types.EventRsvps, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var capacity int
+	err = tx.QueryRow(
+		`SELECT capacity FROM events
+		WHERE id = $1
+		FOR UPDATE`, rsvp.EventId,
+	).Scan(&capacity)
 	if err != nil {
 		return nil, err
 	}
 
-	events := make([]types.EventAddresses, 0)
-	for rows.Next() {
-		event, err := utils.ScanRowIntoNeighborEvents(rows)
+	var previousStatus string
+	err = tx.QueryRow(
+		`SELECT status FROM event_rsvps
+		WHERE event_id = $1
+		AND neighbor_id = $2`, rsvp.EventId, rsvp.NeighborId,
+	).Scan(&previousStatus)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	if rsvp.Status == "going" && previousStatus != "going" && capacity > 0 {
+		var going int
+		err = tx.QueryRow(
+			`SELECT COUNT(*) FROM event_rsvps
+			WHERE event_id = $1
+			AND status = 'going'`, rsvp.EventId,
+		).Scan(&going)
 		if err != nil {
 			return nil, err
 		}
-		events = append(events, *event)
-	}
 
-	return events, nil
-}
+		if going >= capacity {
+			rsvp.Status = "waitlisted"
+		}
+	}
 
-func (s *Store) GetNeighborhoodEventsBeforeDate(neighborhoodId int, dateTime time.Time) ([]types.EventAddresses, error) {
-	rows, err := s.db.Query(
-		`SELECT * FROM events e 
-		LEFT OUTER JOIN addresses a ON a.id = e.address_id
-		WHERE a.neighborhood_id = $1
-		AND start < $2
-		ORDER BY start DESC`, neighborhoodId, dateTime,
-	)
+	saved := new(types.EventRsvps)
+	err = tx.QueryRow(
+		`INSERT INTO event_rsvps (event_id, neighbor_id, status)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (event_id, neighbor_id) DO UPDATE
+		SET status = EXCLUDED.status,
+		responded_at = CASE
+			WHEN event_rsvps.status = EXCLUDED.status THEN event_rsvps.responded_at
+			ELSE CURRENT_TIMESTAMP
+		END
+		RETURNING id, event_id, neighbor_id, status, responded_at`,
+		rsvp.EventId,
+		rsvp.NeighborId,
+		rsvp.Status,
+	).Scan(&saved.Id, &saved.EventId, &saved.NeighborId, &saved.Status, &saved.RespondedAt)
 	if err != nil {
 		return nil, err
 	}
 
-	events := make([]types.EventAddresses, 0)
-	for rows.Next() {
-		event, err := utils.ScanRowIntoNeighborEvents(rows)
-		if err != nil {
+	if previousStatus == "going" && saved.Status != "going" {
+		if err := promoteWaitlisted(tx, rsvp.EventId, capacity); err != nil {
 			return nil, err
 		}
-		events = append(events, *event)
 	}
 
-	return events, nil
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return saved, nil
+}
+
+func (s *Store) CancelRsvp(eventId int, neighborId int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var capacity int
+	err = tx.QueryRow(
+		`SELECT capacity FROM events
+		WHERE id = $1
+		FOR UPDATE`, eventId,
+	).Scan(&capacity)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		`DELETE FROM event_rsvps
+		WHERE event_id = $1
+		AND neighbor_id = $2`, eventId, neighborId,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := promoteWaitlisted(tx, eventId, capacity); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-func (s *Store) GetNeighborhoodEventsAfterDate(neighborhoodId int, dateTime time.Time) ([]types.EventAddresses, error) {
+func (s *Store) GetAttendeesByEventId(eventId int) ([]types.EventAttendees, error) {
 	rows, err := s.db.Query(
-		`SELECT * FROM events e 
-		LEFT OUTER JOIN addresses a ON a.id = e.address_id
-		WHERE a.neighborhood_id = $1
-		AND start > $2
-		ORDER BY start`, neighborhoodId, dateTime,
+		`SELECT r.id, r.neighbor_id, n.username, r.status, r.responded_at
+		FROM event_rsvps r
+		JOIN neighbors n ON n.id = r.neighbor_id
+		WHERE r.event_id = $1
+		ORDER BY r.status, r.responded_at, r.id`, eventId,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	events := make([]types.EventAddresses, 0)
+	attendees := make([]types.EventAttendees, 0)
 	for rows.Next() {
-		event, err := utils.ScanRowIntoNeighborEvents(rows)
+		attendee, err := utils.ScanRowIntoEventAttendees(rows)
 		if err != nil {
 			return nil, err
 		}
-		events = append(events, *event)
+		attendees = append(attendees, *attendee)
 	}
 
-	return events, nil
+	return attendees, nil
 }
 
-/* 4. CITY */
+// fills open spots with waitlisted neighbors, caller must hold the event row lock
+func promoteWaitlisted(tx *sql.Tx, eventId int, capacity int) error {
+	if capacity == 0 {
+		_, err := tx.Exec(
+			`UPDATE event_rsvps
+			SET status = 'going', responded_at = CURRENT_TIMESTAMP
+			WHERE event_id = $1
+			AND status = 'waitlisted'`, eventId,
+		)
+		return err
+	}
 
-func (s *Store) GetEventsByCity(city string, state string, dateTime time.Time) ([]types.EventAddresses, error) {
+	_, err := tx.Exec(
+		`UPDATE event_rsvps
+		SET status = 'going', responded_at = CURRENT_TIMESTAMP
+		WHERE id IN (
+			SELECT id FROM event_rsvps
+			WHERE event_id = $1
+			AND status = 'waitlisted'
+			ORDER BY responded_at, id
+			LIMIT GREATEST($2 - (
+				SELECT COUNT(*) FROM event_rsvps
+				WHERE event_id = $1
+				AND status = 'going'
+			), 0)
+		)`, eventId, capacity,
+	)
+
+	return err
+}
+
+func (s *Store) GetRsvpsByNeighborId(neighborId int) ([]types.EventRsvps, error) {
 	rows, err := s.db.Query(
-		`SELECT * FROM events e
-		LEFT OUTER JOIN addresses a ON a.id = e.address_id
-		WHERE a.city = $1 AND a.state = $2
-		AND start >= $3
-		ORDER BY start`, city, state, dateTime,
+		`SELECT * FROM event_rsvps
+		WHERE neighbor_id = $1
+		ORDER BY responded_at, id`, neighborId,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	events := make([]types.EventAddresses, 0)
+	rsvps := make([]types.EventRsvps, 0)
 	for rows.Next() {
-		event, err := utils.ScanRowIntoNeighborEvents(rows)
+		rsvp, err := utils.ScanRowIntoEventRsvps(rows)
 		if err != nil {
 			return nil, err
 		}
-		events = append(events, *event)
+		rsvps = append(rsvps, *rsvp)
 	}
 
-	return events, nil
+	return rsvps, nil
 }
 
-func (s *Store) GetCityEventsOnDate(city string, state string, zipcode string, dateTime time.Time) ([]types.EventAddresses, error) {
+/* 5. INVITES */
+
+func (s *Store) CreateEventInvite(invite types.EventInvites) error {
+	_, err := s.db.Exec(
+		`INSERT INTO event_invites (event_id, invited_neighbor_id)
+		VALUES ($1, $2)
+		ON CONFLICT (event_id, invited_neighbor_id) DO NOTHING`,
+		invite.EventId,
+		invite.InvitedNeighborId,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) GetEventInvite(eventId int, neighborId int) (*types.EventInvites, error) {
 	rows, err := s.db.Query(
-		`SELECT * FROM events e
-		LEFT OUTER JOIN addresses a ON a.id = e.address_id
-		WHERE a.city = $1 AND a.state = $2 AND a.zipcode = $3
-		AND start = $4
-		ORDER BY start`, city, state, zipcode, dateTime,
+		`SELECT * FROM event_invites
+		WHERE event_id = $1
+		AND invited_neighbor_id = $2`, eventId, neighborId,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	events := make([]types.EventAddresses, 0)
+	invite := new(types.EventInvites)
 	for rows.Next() {
-		event, err := utils.ScanRowIntoNeighborEvents(rows)
+		invite, err = utils.ScanRowIntoEventInvites(rows)
 		if err != nil {
 			return nil, err
 		}
-		events = append(events, *event)
 	}
 
-	return events, nil
+	return invite, nil
 }
 
-func (s *Store) GetCityEventsBeforeDate(city string, state string, zipcode string, dateTime time.Time) ([]types.EventAddresses, error) {
+// invites from hosts on either side of a block aren't listed
+func (s *Store) GetEventInvitesByNeighborId(neighborId int) ([]types.NeighborEventInvites, error) {
 	rows, err := s.db.Query(
-		`SELECT * FROM events e
-		LEFT OUTER JOIN addresses a ON a.id = e.address_id
-		WHERE a.city = $1 AND a.state = $2 AND a.zipcode = $3
-		AND start < $4
-		ORDER BY start DESC`, city, state, zipcode, dateTime,
+		`SELECT
+			i.id,
+			i.status,
+			i.invited_at,
+			e.id,
+			e.name,
+			e.description,
+			e.start,
+			e."end",
+			e.host_id,
+			n.username
+		FROM event_invites i
+		JOIN events e ON e.id = i.event_id
+		JOIN neighbors n ON n.id = e.host_id
+		WHERE i.invited_neighbor_id = $1
+		AND NOT EXISTS (
+			SELECT 1 FROM blocks b
+			WHERE (b.neighbor_id = $1 AND b.blocked_neighbor_id = e.host_id)
+			OR (b.neighbor_id = e.host_id AND b.blocked_neighbor_id = $1)
+		)
+		ORDER BY e.start`, neighborId,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	events := make([]types.EventAddresses, 0)
+	invites := make([]types.NeighborEventInvites, 0)
 	for rows.Next() {
-		event, err := utils.ScanRowIntoNeighborEvents(rows)
+		invite, err := utils.ScanRowIntoNeighborEventInvites(rows)
 		if err != nil {
 			return nil, err
 		}
-		events = append(events, *event)
+		invites = append(invites, *invite)
 	}
 
-	return events, nil
+	return invites, nil
 }
 
-func (s *Store) GetCityEventsAfterDate(city string, state string, zipcode string, dateTime time.Time) ([]types.EventAddresses, error) {
+// which of the events the neighbor is invited to, declining an invite gives up what it let them see
+func (s *Store) GetInvitedEventIds(neighborId int, eventIds []int) ([]int, error) {
 	rows, err := s.db.Query(
-		`SELECT * FROM events e
-		LEFT OUTER JOIN addresses a ON a.id = e.address_id
-		WHERE a.city = $1 AND a.state = $2 AND a.zipcode = $3
-		AND start > $4
-		ORDER BY start`, city, state, zipcode, dateTime,
+		`SELECT event_id FROM event_invites
+		WHERE invited_neighbor_id = $1
+		AND event_id = ANY($2)
+		AND status <> 'declined'`, neighborId, eventIds,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	events := make([]types.EventAddresses, 0)
+	invitedEventIds := make([]int, 0)
 	for rows.Next() {
-		event, err := utils.ScanRowIntoNeighborEvents(rows)
-		if err != nil {
+		var eventId int
+		if err := rows.Scan(&eventId); err != nil {
 			return nil, err
 		}
-		events = append(events, *event)
+		invitedEventIds = append(invitedEventIds, eventId)
 	}
 
-	return events, nil
+	return invitedEventIds, nil
 }
 
-/* 5. ALL, needed? */
-
-// func (s *Store) GetAllEvents(dateTime time.Time) ([]types.EventAddresses, error) {
-// 	rows, err := s.db.Query(
-// 		`SELECT * FROM events e
-// 		LEFT OUTER JOIN addresses a ON a.id = e.address_id
-// 		WHERE start >= $1`, dateTime,
-// 	)
-// 	if err != nil {
-// 		return nil, err
-// 	}
-
-// 	events := make([]types.EventAddresses, 0)
-// 	for rows.Next() {
-// 		event, err := utils.ScanRowIntoNeighborEvents(rows)
-// 		if err != nil {
-// 			return nil, err
-// 		}
-// 		events = append(events, *event)
-// 	}
-
-// 	return events, nil
-// }
-
-/* 6. GENERAL */
-
-func (s *Store) CreateEvent(event types.Events) error {
+func (s *Store) UpdateEventInvite(invite types.EventInvites) error {
 	_, err := s.db.Exec(
-		`INSERT INTO events (
-			name,
-			description,
-			start,
-			"end",
-			reoccurrence,
-			for_unloggedins,
-			for_unverifieds,
-			invite_only,
-			host_id,
-			address_id
-		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
-		event.Name,
-		event.Description,
-		event.Start,
-		event.End,
-		event.Reoccurrence,
-		event.ForUnloggedins,
-		event.ForUnverifieds,
-		event.InviteOnly,
-		event.HostId,
-		event.AddressId,
+		`UPDATE event_invites
+		SET status = $1
+		WHERE event_id = $2
+		AND invited_neighbor_id = $3`,
+		invite.Status,
+		invite.EventId,
+		invite.InvitedNeighborId,
 	)
 	if err != nil {
 		return err
@@ -404,3 +807,26 @@ func (s *Store) CreateEvent(event types.Events) error {
 
 	return nil
 }
+
+/* 6. CALENDAR */
+
+func (s *Store) GetEventWithAddressById(id int) (*types.EventAddresses, error) {
+	rows, err := s.db.Query(
+		`SELECT * FROM events e
+		LEFT OUTER JOIN addresses a ON a.id = e.address_id
+		WHERE e.id = $1`, id,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	event := new(types.EventAddresses)
+	for rows.Next() {
+		event, err = utils.ScanRowIntoNeighborEvents(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return event, nil
+}
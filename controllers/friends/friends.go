@@ -15,13 +15,31 @@ func NewStore(db *sql.DB) *Store {
 	return &Store{db: db}
 }
 
-func (s *Store) GetFriendsByNeighborId(neighborId int) ([]types.FriendsList, error) {
+// a friendship is one row with the smaller id first, so it reads the same from both sides
+const insertFriend = `INSERT INTO friends (
+	neighbor_id,
+	neighbors_friend_id
+)
+VALUES (LEAST($1::int, $2::int), GREATEST($1::int, $2::int))
+ON CONFLICT (neighbor_id, neighbors_friend_id) DO NOTHING`
+
+// the neighbor can be on either side of the row, the friend is the other one.
+// names come from the friend's latest home address, friends without one sort first
+func (s *Store) GetFriendsByNeighborId(neighborId int, page types.Pagination) ([]types.FriendsList, error) {
 	rows, err := s.db.Query(
-		`SELECT * FROM friends f
-		JOIN neighbors n ON n.id = f.neighbor_id
-		JOIN addresses a ON a.neighbor_id = f.neighbor_id
-		WHERE f.neighbor_id = $1
-		ORDER BY a.first_name`, neighborId,
+		`SELECT f.id, n.id, n.username, COALESCE(a.first_name, ''), COALESCE(a.last_name, ''), f.friended_at
+		FROM friends f
+		JOIN neighbors n ON n.id = CASE WHEN f.neighbor_id = $1 THEN f.neighbors_friend_id ELSE f.neighbor_id END
+		LEFT JOIN LATERAL (
+			SELECT first_name, last_name FROM addresses
+			WHERE neighbor_id = n.id
+			ORDER BY LOWER(type) = 'home' DESC, recorded_at DESC, id DESC
+			LIMIT 1
+		) a ON TRUE
+		WHERE (f.neighbor_id = $1 OR f.neighbors_friend_id = $1)
+		AND (COALESCE(a.first_name, ''), f.id) > ($2, $3)
+		ORDER BY COALESCE(a.first_name, ''), f.id
+		LIMIT NULLIF($4, 0)`, neighborId, page.After.Key, page.After.Id, page.Limit,
 	)
 	if err != nil {
 		return nil, err
@@ -39,32 +57,90 @@ func (s *Store) GetFriendsByNeighborId(neighborId int) ([]types.FriendsList, err
 	return friends, nil
 }
 
-func (s *Store) CreateFriendRequest(friendRequest types.FriendRequests) error {
-	_, err := s.db.Exec(
+// asking someone who already asked accepts their request and asking again after being declined reopens it.
+// the pair is locked so two neighbors asking each other at once end up friends instead of with two requests
+func (s *Store) CreateFriendRequest(friendRequest types.FriendRequests) (string, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		`SELECT pg_advisory_xact_lock(LEAST($1::int, $2::int), GREATEST($1::int, $2::int))`,
+		friendRequest.NeighborId, friendRequest.RequestedFriendId,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	var areFriends bool
+	err = tx.QueryRow(
+		`SELECT EXISTS (
+			SELECT 1 FROM friends
+			WHERE neighbor_id = LEAST($1::int, $2::int) AND neighbors_friend_id = GREATEST($1::int, $2::int)
+		)`, friendRequest.NeighborId, friendRequest.RequestedFriendId,
+	).Scan(&areFriends)
+	if err != nil {
+		return "", err
+	}
+
+	if areFriends {
+		return types.AlreadyFriends, nil
+	}
+
+	accepted, err := acceptFriendRequest(tx, friendRequest.RequestedFriendId, friendRequest.NeighborId)
+	if err != nil {
+		return "", err
+	}
+
+	if accepted {
+		return types.RequestAccepted, tx.Commit()
+	}
+
+	result, err := tx.Exec(
 		`INSERT INTO friend_requests (
 			neighbor_id,
 			requested_friend_id,
 			status
 		)
-		VALUES ($1, $2, $3)`,
+		VALUES ($1, $2, 'pending')
+		ON CONFLICT (neighbor_id, requested_friend_id) DO UPDATE
+		SET status = EXCLUDED.status, friend_requested_at = CURRENT_TIMESTAMP
+		WHERE friend_requests.status <> 'pending'`,
 		friendRequest.NeighborId,
 		friendRequest.RequestedFriendId,
-		friendRequest.Status,
 	)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	return nil
+	created, err := result.RowsAffected()
+	if err != nil {
+		return "", err
+	}
+
+	if created == 0 {
+		return types.AlreadyPending, nil
+	}
+
+	return types.RequestPending, tx.Commit()
 }
 
-func (s *Store) GetFriendRequestsByNeighborId(requestedFriendId int) ([]types.PendingFriendRequests, error) {
+func (s *Store) GetFriendRequestsByNeighborId(requestedFriendId int, page types.Pagination) ([]types.PendingFriendRequests, error) {
 	rows, err := s.db.Query(
-		`SELECT * FROM friend_requests f
-		JOIN neighbors n ON n.id = f.requested_friend_id
+		`SELECT f.id, f.neighbor_id, f.requested_friend_id, f.status, f.friend_requested_at, n.username
+		FROM friend_requests f
+		JOIN neighbors n ON n.id = f.neighbor_id
 		WHERE f.requested_friend_id = $1
 		AND f.status = 'pending'
-		ORDER BY n.username`, requestedFriendId,
+		AND NOT EXISTS (
+			SELECT 1 FROM blocks b
+			WHERE b.neighbor_id = f.neighbor_id AND b.blocked_neighbor_id = f.requested_friend_id
+		)
+		AND (n.username, f.id) > ($2, $3)
+		ORDER BY n.username, f.id
+		LIMIT NULLIF($4, 0)`, requestedFriendId, page.After.Key, page.After.Id, page.Limit,
 	)
 	if err != nil {
 		return nil, err
@@ -82,17 +158,78 @@ func (s *Store) GetFriendRequestsByNeighborId(requestedFriendId int) ([]types.Pe
 	return friendRequests, nil
 }
 
-// respond to friend request controller
-func (s *Store) UpdateFriendRequest(friendRequest types.FriendRequests) error {
-	_, err := s.db.Exec(
+// respond to friend request controller, only pending requests can be answered
+func (s *Store) UpdateFriendRequest(friendRequest types.FriendRequests) (bool, error) {
+	result, err := s.db.Exec(
 		`UPDATE friend_requests
 		SET status = $1
 		WHERE neighbor_id = $2
-		AND requested_friend_id = $3`,
+		AND requested_friend_id = $3
+		AND status = 'pending'`,
 		friendRequest.Status,
 		friendRequest.NeighborId,
 		friendRequest.RequestedFriendId,
 	)
+	if err != nil {
+		return false, err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return updated == 1, nil
+}
+
+// accepts friendRequest.NeighborId's pending request and makes them friends in one go
+func (s *Store) AcceptFriendRequest(friendRequest types.FriendRequests) (bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	accepted, err := acceptFriendRequest(tx, friendRequest.NeighborId, friendRequest.RequestedFriendId)
+	if err != nil || !accepted {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func acceptFriendRequest(tx *sql.Tx, neighborId int, requestedFriendId int) (bool, error) {
+	result, err := tx.Exec(
+		`UPDATE friend_requests
+		SET status = 'accepted'
+		WHERE neighbor_id = $1
+		AND requested_friend_id = $2
+		AND status = 'pending'`,
+		neighborId,
+		requestedFriendId,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	accepted, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	if accepted == 0 {
+		return false, nil
+	}
+
+	if _, err := tx.Exec(insertFriend, neighborId, requestedFriendId); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (s *Store) CreateFriend(friends types.Friends) error {
+	_, err := s.db.Exec(insertFriend, friends.NeighborId, friends.NeighborsFriendId)
 	if err != nil {
 		return err
 	}
@@ -100,17 +237,403 @@ func (s *Store) UpdateFriendRequest(friendRequest types.FriendRequests) error {
 	return nil
 }
 
-func (s *Store) CreateFriend(friends types.Friends) error {
-	_, err := s.db.Exec(
-		`INSERT INTO friends (
-			neighbor_id,
-			neighbors_friend_id
-		)
-		VALUES ($1, $2)`, friends.NeighborId, friends.NeighborsFriendId,
+// which of the neighbors are friends with neighborId, who can be on either side of a friendship
+func (s *Store) GetFriendIds(neighborId int, neighborIds []int) ([]int, error) {
+	rows, err := s.db.Query(
+		`SELECT neighbors_friend_id FROM friends
+		WHERE neighbor_id = $1 AND neighbors_friend_id = ANY($2)
+		UNION
+		SELECT neighbor_id FROM friends
+		WHERE neighbors_friend_id = $1 AND neighbor_id = ANY($2)`, neighborId, neighborIds,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	friendIds := make([]int, 0)
+	for rows.Next() {
+		var friendId int
+		if err := rows.Scan(&friendId); err != nil {
+			return nil, err
+		}
+		friendIds = append(friendIds, friendId)
+	}
+
+	return friendIds, nil
+}
+
+func (s *Store) AreFriends(neighborId int, friendId int) (bool, error) {
+	var areFriends bool
+	err := s.db.QueryRow(
+		`SELECT EXISTS (
+			SELECT 1 FROM friends
+			WHERE (neighbor_id = $1 AND neighbors_friend_id = $2)
+			OR (neighbor_id = $2 AND neighbors_friend_id = $1)
+		)`, neighborId, friendId,
+	).Scan(&areFriends)
+	if err != nil {
+		return false, err
+	}
+
+	return areFriends, nil
+}
+
+// the requests between them go too so either can ask again
+func (s *Store) DeleteFriend(neighborId int, friendId int) (bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		`DELETE FROM friends
+		WHERE (neighbor_id = $1 AND neighbors_friend_id = $2)
+		OR (neighbor_id = $2 AND neighbors_friend_id = $1)`,
+		neighborId, friendId,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	if deleted == 0 {
+		return false, nil
+	}
+
+	_, err = tx.Exec(
+		`DELETE FROM friend_requests
+		WHERE (neighbor_id = $1 AND requested_friend_id = $2)
+		OR (neighbor_id = $2 AND requested_friend_id = $1)`,
+		neighborId, friendId,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// only pending requests can be withdrawn
+func (s *Store) DeleteFriendRequest(neighborId int, requestedFriendId int) (bool, error) {
+	result, err := s.db.Exec(
+		`DELETE FROM friend_requests
+		WHERE neighbor_id = $1
+		AND requested_friend_id = $2
+		AND status = 'pending'`,
+		neighborId, requestedFriendId,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return deleted > 0, nil
+}
+
+func (s *Store) GetBlocksByNeighborId(neighborId int) ([]types.Blocks, error) {
+	rows, err := s.db.Query(
+		`SELECT * FROM blocks
+		WHERE neighbor_id = $1
+		ORDER BY id`, neighborId,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	blocks := make([]types.Blocks, 0)
+	for rows.Next() {
+		block, err := utils.ScanRowIntoBlocks(rows)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, *block)
+	}
+
+	return blocks, nil
+}
+
+// which of the neighbors blocked neighborId, so what they share can be hidden from them
+func (s *Store) GetBlockerIds(neighborId int, neighborIds []int) ([]int, error) {
+	rows, err := s.db.Query(
+		`SELECT neighbor_id FROM blocks
+		WHERE blocked_neighbor_id = $1
+		AND neighbor_id = ANY($2)`, neighborId, neighborIds,
 	)
+	if err != nil {
+		return nil, err
+	}
+
+	blockerIds := make([]int, 0)
+	for rows.Next() {
+		var blockerId int
+		if err := rows.Scan(&blockerId); err != nil {
+			return nil, err
+		}
+		blockerIds = append(blockerIds, blockerId)
+	}
+
+	return blockerIds, nil
+}
+
+func (s *Store) IsBlocked(neighborId int, blockedNeighborId int) (bool, error) {
+	var isBlocked bool
+	err := s.db.QueryRow(
+		`SELECT EXISTS (
+			SELECT 1 FROM blocks
+			WHERE neighbor_id = $1 AND blocked_neighbor_id = $2
+		)`, neighborId, blockedNeighborId,
+	).Scan(&isBlocked)
+	if err != nil {
+		return false, err
+	}
+
+	return isBlocked, nil
+}
+
+// blocking someone already blocked does nothing, either way the friendship, requests
+// and the invites between them to each other's events are gone afterwards
+func (s *Store) CreateBlock(block types.Blocks) error {
+	tx, err := s.db.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	return nil
+	statements := []string{
+		`INSERT INTO blocks (neighbor_id, blocked_neighbor_id)
+		VALUES ($1, $2)
+		ON CONFLICT (neighbor_id, blocked_neighbor_id) DO NOTHING`,
+		`DELETE FROM friends
+		WHERE (neighbor_id = $1 AND neighbors_friend_id = $2)
+		OR (neighbor_id = $2 AND neighbors_friend_id = $1)`,
+		`DELETE FROM friend_requests
+		WHERE (neighbor_id = $1 AND requested_friend_id = $2)
+		OR (neighbor_id = $2 AND requested_friend_id = $1)`,
+		`DELETE FROM event_invites
+		WHERE (invited_neighbor_id = $2 AND event_id IN (SELECT id FROM events WHERE host_id = $1))
+		OR (invited_neighbor_id = $1 AND event_id IN (SELECT id FROM events WHERE host_id = $2))`,
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement, block.NeighborId, block.BlockedNeighborId); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *Store) DeleteBlock(neighborId int, blockedNeighborId int) (bool, error) {
+	result, err := s.db.Exec(
+		`DELETE FROM blocks
+		WHERE neighbor_id = $1 AND blocked_neighbor_id = $2`,
+		neighborId, blockedNeighborId,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return deleted == 1, nil
+}
+
+// both directions, for exporting the neighbor's data
+func (s *Store) GetFriendshipsByNeighborId(neighborId int) ([]types.Friends, error) {
+	rows, err := s.db.Query(
+		`SELECT * FROM friends
+		WHERE neighbor_id = $1 OR neighbors_friend_id = $1
+		ORDER BY id`, neighborId,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	friends := make([]types.Friends, 0)
+	for rows.Next() {
+		friend, err := utils.ScanRowIntoFriends(rows)
+		if err != nil {
+			return nil, err
+		}
+		friends = append(friends, *friend)
+	}
+
+	return friends, nil
+}
+
+// sent and received, whatever their status
+func (s *Store) GetAllFriendRequestsByNeighborId(neighborId int) ([]types.FriendRequests, error) {
+	rows, err := s.db.Query(
+		`SELECT * FROM friend_requests
+		WHERE neighbor_id = $1 OR requested_friend_id = $1
+		ORDER BY id`, neighborId,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	friendRequests := make([]types.FriendRequests, 0)
+	for rows.Next() {
+		friendRequest, err := utils.ScanRowIntoFriendRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		friendRequests = append(friendRequests, *friendRequest)
+	}
+
+	return friendRequests, nil
+}
+
+// mutual friends count most, then events both went to or hosted, then living nearby and shared interests.
+// friends, anyone with a request either way, blocks either way and neighbors leaving are left out.
+// unassigned neighborhoods aren't shared and only interests the viewer is allowed to see count.
+// only neighbors with something in common are scored, so a page costs what the viewer's circle does, not every neighbor
+func (s *Store) GetFriendSuggestions(neighborId int, limit int, cursor *types.SuggestionCursor) ([]types.FriendSuggestions, error) {
+	var after types.SuggestionCursor
+	if cursor != nil {
+		after = *cursor
+	}
+
+	rows, err := s.db.Query(
+		`WITH me AS (
+			SELECT n.zipcode, (
+				SELECT NULLIF(a.neighborhood_id, $5) FROM addresses a
+				WHERE a.neighbor_id = n.id
+				ORDER BY LOWER(a.type) = 'home' DESC, a.recorded_at DESC, a.id DESC
+				LIMIT 1
+			) AS neighborhood_id
+			FROM neighbors n
+			WHERE n.id = $1
+		),
+		my_friends AS (
+			SELECT neighbors_friend_id AS friend_id FROM friends WHERE neighbor_id = $1
+			UNION
+			SELECT neighbor_id FROM friends WHERE neighbors_friend_id = $1
+		),
+		mutual AS (
+			SELECT CASE WHEN f.neighbor_id = m.friend_id THEN f.neighbors_friend_id ELSE f.neighbor_id END AS neighbor_id,
+				COUNT(*) AS mutual_friends
+			FROM friends f
+			JOIN my_friends m ON m.friend_id IN (f.neighbor_id, f.neighbors_friend_id)
+			GROUP BY 1
+		),
+		my_events AS (
+			SELECT event_id FROM event_rsvps WHERE neighbor_id = $1 AND status = 'going'
+			UNION
+			SELECT id FROM events WHERE host_id = $1 AND status <> 'cancelled'
+		),
+		shared_events AS (
+			SELECT attendance.neighbor_id, COUNT(*) AS shared_events
+			FROM (
+				SELECT r.event_id, r.neighbor_id FROM event_rsvps r
+				JOIN my_events m ON m.event_id = r.event_id
+				WHERE r.status = 'going'
+				UNION
+				SELECT e.id, e.host_id FROM events e
+				JOIN my_events m ON m.event_id = e.id
+				WHERE e.status <> 'cancelled'
+			) attendance
+			GROUP BY attendance.neighbor_id
+		),
+		matching_interests AS (
+			SELECT theirs.neighbor_id, theirs.visibility
+			FROM interests mine
+			JOIN interests theirs ON theirs.interest_id = mine.interest_id
+			WHERE mine.neighbor_id = $1 AND theirs.neighbor_id <> $1
+		),
+		candidates AS (
+			SELECT neighbor_id AS id FROM mutual
+			UNION
+			SELECT neighbor_id FROM shared_events
+			UNION
+			SELECT neighbor_id FROM matching_interests
+			UNION
+			SELECT a.neighbor_id FROM addresses a JOIN me ON a.neighborhood_id = me.neighborhood_id
+			UNION
+			SELECT n.id FROM neighbors n JOIN me ON n.zipcode = me.zipcode
+		),
+		neighborhoods AS (
+			SELECT DISTINCT ON (a.neighbor_id) a.neighbor_id, NULLIF(a.neighborhood_id, $5) AS neighborhood_id
+			FROM addresses a
+			JOIN candidates c ON c.id = a.neighbor_id
+			ORDER BY a.neighbor_id, LOWER(a.type) = 'home' DESC, a.recorded_at DESC, a.id DESC
+		),
+		shared_interests AS (
+			SELECT mi.neighbor_id, COUNT(*) AS shared_interests
+			FROM matching_interests mi
+			LEFT JOIN neighborhoods h ON h.neighbor_id = mi.neighbor_id
+			CROSS JOIN me
+			WHERE mi.visibility = 'public'
+			OR (mi.visibility = 'neighborhood' AND h.neighborhood_id = me.neighborhood_id)
+			GROUP BY mi.neighbor_id
+		),
+		suggestions AS (
+			SELECT n.id,
+				n.username,
+				COALESCE(mf.mutual_friends, 0) AS mutual_friends,
+				COALESCE(h.neighborhood_id = me.neighborhood_id, FALSE) AS same_neighborhood,
+				n.zipcode = me.zipcode AS same_zipcode,
+				COALESCE(se.shared_events, 0) AS shared_events,
+				COALESCE(si.shared_interests, 0) AS shared_interests
+			FROM candidates c
+			JOIN neighbors n ON n.id = c.id
+			CROSS JOIN me
+			LEFT JOIN neighborhoods h ON h.neighbor_id = n.id
+			LEFT JOIN mutual mf ON mf.neighbor_id = n.id
+			LEFT JOIN shared_events se ON se.neighbor_id = n.id
+			LEFT JOIN shared_interests si ON si.neighbor_id = n.id
+			WHERE n.id <> $1
+			AND n.id NOT IN (SELECT friend_id FROM my_friends)
+			AND NOT EXISTS (
+				SELECT 1 FROM friend_requests fr
+				WHERE (fr.neighbor_id = $1 AND fr.requested_friend_id = n.id)
+				OR (fr.neighbor_id = n.id AND fr.requested_friend_id = $1)
+			)
+			AND NOT EXISTS (
+				SELECT 1 FROM blocks b
+				WHERE (b.neighbor_id = $1 AND b.blocked_neighbor_id = n.id)
+				OR (b.neighbor_id = n.id AND b.blocked_neighbor_id = $1)
+			)
+			AND NOT EXISTS (SELECT 1 FROM account_deletions d WHERE d.neighbor_id = n.id)
+		),
+		scored AS (
+			SELECT *,
+				mutual_friends * 3
+				+ shared_events * 2
+				+ CASE WHEN same_neighborhood THEN 2 ELSE 0 END
+				+ CASE WHEN same_zipcode THEN 1 ELSE 0 END
+				+ shared_interests AS score
+			FROM suggestions
+		)
+		SELECT * FROM scored
+		WHERE score > 0
+		AND ($2 = 0 OR score < $3 OR (score = $3 AND id > $2))
+		ORDER BY score DESC, id
+		LIMIT NULLIF($4, 0)`, neighborId, after.Id, after.Score, limit, utils.UnassignedNeighborhoodId,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := make([]types.FriendSuggestions, 0)
+	for rows.Next() {
+		suggestion, err := utils.ScanRowIntoFriendSuggestions(rows)
+		if err != nil {
+			return nil, err
+		}
+		suggestions = append(suggestions, *suggestion)
+	}
+
+	return suggestions, nil
 }
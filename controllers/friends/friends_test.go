@@ -0,0 +1,247 @@
+package friends
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	postgresMigrate "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	_ "github.com/jackc/pgx/v4/stdlib"
+	"github.com/jamesdavidyu/neighborhost-service/cmd/model/types"
+	"github.com/jamesdavidyu/neighborhost-service/utils"
+)
+
+// the store tests need a postgres database they can migrate, TEST_DATABASE_URL points at it.
+// make test-db starts a throwaway one in docker and runs every test against it
+func testDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL isn't set, run make test-db to include the store tests")
+	}
+
+	db, err := sql.Open("pgx", url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	driver, err := postgresMigrate.WithInstance(db, &postgresMigrate.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := migrate.NewWithDatabaseInstance("file://../../cmd/model/migrate/migrations", "postgres", driver)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+		t.Fatal(err)
+	}
+
+	// addresses and neighbors default to neighborhood 1
+	statements := []string{
+		`INSERT INTO zipcodes (zipcode, city, state, timezone) VALUES
+			('11111', 'Test', 'Test', 'America/New_York'),
+			('22222', 'Test', 'Test', 'America/New_York')
+		ON CONFLICT (zipcode) DO NOTHING`,
+		`INSERT INTO neighborhoods (id, neighborhood) VALUES (1, 'Unassigned') ON CONFLICT (id) DO NOTHING`,
+		`SELECT setval(pg_get_serial_sequence('neighborhoods', 'id'), (SELECT MAX(id) FROM neighborhoods))`,
+	}
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return db
+}
+
+func createNeighborhood(t *testing.T, db *sql.DB) int {
+	t.Helper()
+
+	var id int
+	if err := db.QueryRow(`INSERT INTO neighborhoods (neighborhood) VALUES ('Test') RETURNING id`).Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+
+	return id
+}
+
+var neighbors int
+
+// a neighbor with a home address in the neighborhood
+func createNeighbor(t *testing.T, db *sql.DB, zipcode string, neighborhoodId int) int {
+	t.Helper()
+
+	neighbors++
+	username := fmt.Sprintf("t%d_%d", time.Now().UnixNano(), neighbors)
+
+	var id int
+	err := db.QueryRow(
+		`INSERT INTO neighbors (email, username, zipcode, password, ip)
+		VALUES ($1, $2, $3, 'password', '127.0.0.1')
+		RETURNING id`, username+"@example.com", username, zipcode,
+	).Scan(&id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = db.Exec(
+		`INSERT INTO addresses (first_name, last_name, address, city, state, zipcode, type, neighbor_id, neighborhood_id)
+		VALUES ('Test', 'Test', '1 Test St', 'Test', 'Test', $1, 'Home', $2, $3)`, zipcode, id, neighborhoodId,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return id
+}
+
+func addInterest(t *testing.T, db *sql.DB, neighborId int, interest string, visibility string) {
+	t.Helper()
+
+	_, err := db.Exec(
+		`INSERT INTO interests (neighbor_id, interest_id, visibility)
+		SELECT $1, id, $3 FROM interest_taxonomy WHERE interest = $2`, neighborId, interest, visibility,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func suggestionsFor(t *testing.T, store *Store, neighborId int) map[int]types.FriendSuggestions {
+	t.Helper()
+
+	suggestions, err := store.GetFriendSuggestions(neighborId, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	byId := make(map[int]types.FriendSuggestions)
+	for _, suggestion := range suggestions {
+		byId[suggestion.NeighborId] = suggestion
+	}
+
+	return byId
+}
+
+func TestGetFriendSuggestions(t *testing.T) {
+	db := testDB(t)
+	store := NewStore(db)
+
+	t.Run("nothing in common", func(t *testing.T) {
+		viewer := createNeighbor(t, db, "11111", utils.UnassignedNeighborhoodId)
+		stranger := createNeighbor(t, db, "22222", utils.UnassignedNeighborhoodId)
+
+		if suggestion, ok := suggestionsFor(t, store, viewer)[stranger]; ok {
+			t.Errorf("stranger was suggested: %+v", suggestion)
+		}
+	})
+
+	t.Run("same neighborhood", func(t *testing.T) {
+		neighborhoodId := createNeighborhood(t, db)
+		viewer := createNeighbor(t, db, "11111", neighborhoodId)
+		nearby := createNeighbor(t, db, "22222", neighborhoodId)
+
+		suggestion, ok := suggestionsFor(t, store, viewer)[nearby]
+		if !ok || !suggestion.SameNeighborhood || suggestion.Score != 2 {
+			t.Errorf("got %+v, want the same neighborhood scored 2", suggestion)
+		}
+	})
+
+	t.Run("mutual friends", func(t *testing.T) {
+		viewer := createNeighbor(t, db, "11111", utils.UnassignedNeighborhoodId)
+		friend := createNeighbor(t, db, "22222", utils.UnassignedNeighborhoodId)
+		friendOfFriend := createNeighbor(t, db, "22222", utils.UnassignedNeighborhoodId)
+		blocked := createNeighbor(t, db, "22222", utils.UnassignedNeighborhoodId)
+
+		for _, friends := range []types.Friends{
+			{NeighborId: viewer, NeighborsFriendId: friend},
+			{NeighborId: friendOfFriend, NeighborsFriendId: friend},
+			{NeighborId: friend, NeighborsFriendId: blocked},
+		} {
+			if err := store.CreateFriend(friends); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err := store.CreateBlock(types.Blocks{NeighborId: blocked, BlockedNeighborId: viewer}); err != nil {
+			t.Fatal(err)
+		}
+
+		suggestions := suggestionsFor(t, store, viewer)
+		if suggestion := suggestions[friendOfFriend]; suggestion.MutualFriends != 1 {
+			t.Errorf("got %+v, want 1 mutual friend", suggestion)
+		}
+		if _, ok := suggestions[friend]; ok {
+			t.Error("an existing friend was suggested")
+		}
+		if _, ok := suggestions[blocked]; ok {
+			t.Error("a neighbor who blocked the viewer was suggested")
+		}
+	})
+
+	t.Run("interests the viewer can't see", func(t *testing.T) {
+		viewer := createNeighbor(t, db, "11111", utils.UnassignedNeighborhoodId)
+		public := createNeighbor(t, db, "22222", utils.UnassignedNeighborhoodId)
+		private := createNeighbor(t, db, "22222", utils.UnassignedNeighborhoodId)
+
+		addInterest(t, db, viewer, "Running", "private")
+		addInterest(t, db, public, "Running", "public")
+		addInterest(t, db, private, "Running", "friends")
+
+		suggestions := suggestionsFor(t, store, viewer)
+		if suggestion := suggestions[public]; suggestion.SharedInterests != 1 {
+			t.Errorf("got %+v, want 1 shared interest", suggestion)
+		}
+		if suggestion, ok := suggestions[private]; ok {
+			t.Errorf("a hidden interest was counted: %+v", suggestion)
+		}
+	})
+}
+
+func TestCreateFriendRequestBothWays(t *testing.T) {
+	db := testDB(t)
+	store := NewStore(db)
+
+	for i := 0; i < 20; i++ {
+		a := createNeighbor(t, db, "11111", utils.UnassignedNeighborhoodId)
+		b := createNeighbor(t, db, "22222", utils.UnassignedNeighborhoodId)
+
+		statuses := make([]string, 2)
+		var wg sync.WaitGroup
+		for j, request := range []types.FriendRequests{
+			{NeighborId: a, RequestedFriendId: b},
+			{NeighborId: b, RequestedFriendId: a},
+		} {
+			wg.Add(1)
+			go func(j int, request types.FriendRequests) {
+				defer wg.Done()
+
+				status, err := store.CreateFriendRequest(request)
+				if err != nil {
+					t.Error(err)
+				}
+				statuses[j] = status
+			}(j, request)
+		}
+		wg.Wait()
+
+		areFriends, err := store.AreFriends(a, b)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !areFriends {
+			t.Fatalf("got %q and no friendship, want one request accepted", statuses)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package images
+
+import (
+	"database/sql"
+
+	"github.com/jamesdavidyu/neighborhost-service/cmd/model/types"
+	"github.com/jamesdavidyu/neighborhost-service/utils"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) GetAvatarByNeighborId(neighborId int) (*types.Images, error) {
+	rows, err := s.db.Query(
+		`SELECT i.* FROM images i
+		JOIN avatars a ON a.image_id = i.id
+		WHERE a.neighbor_id = $1`, neighborId,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	image := new(types.Images)
+	for rows.Next() {
+		image, err = utils.ScanRowIntoImages(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return image, nil
+}
+
+// the avatar it replaces is left for the sweep
+func (s *Store) CreateAvatar(neighborId int, image types.Images) error {
+	return s.link(
+		`INSERT INTO avatars (neighbor_id, image_id)
+		VALUES ($1, $2)
+		ON CONFLICT (neighbor_id) DO UPDATE
+		SET image_id = EXCLUDED.image_id`,
+		neighborId, image,
+	)
+}
+
+func (s *Store) DeleteAvatar(neighborId int) (bool, error) {
+	result, err := s.db.Exec("DELETE FROM avatars WHERE neighbor_id = $1", neighborId)
+	if err != nil {
+		return false, err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return deleted == 1, nil
+}
+
+func (s *Store) GetEventCoverByEventId(eventId int) (*types.Images, error) {
+	rows, err := s.db.Query(
+		`SELECT i.* FROM images i
+		JOIN event_covers c ON c.image_id = i.id
+		WHERE c.event_id = $1`, eventId,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	image := new(types.Images)
+	for rows.Next() {
+		image, err = utils.ScanRowIntoImages(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return image, nil
+}
+
+func (s *Store) CreateEventCover(eventId int, image types.Images) error {
+	return s.link(
+		`INSERT INTO event_covers (event_id, image_id)
+		VALUES ($1, $2)
+		ON CONFLICT (event_id) DO UPDATE
+		SET image_id = EXCLUDED.image_id`,
+		eventId, image,
+	)
+}
+
+func (s *Store) DeleteEventCover(eventId int) (bool, error) {
+	result, err := s.db.Exec("DELETE FROM event_covers WHERE event_id = $1", eventId)
+	if err != nil {
+		return false, err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return deleted == 1, nil
+}
+
+// orphans are images no avatar or cover points at, e.g. replaced ones or ones whose neighbor or event was deleted
+func (s *Store) GetOrphanedImages(limit int) ([]types.Images, error) {
+	rows, err := s.db.Query(
+		`SELECT i.* FROM images i
+		WHERE NOT EXISTS (SELECT 1 FROM avatars a WHERE a.image_id = i.id)
+		AND NOT EXISTS (SELECT 1 FROM event_covers c WHERE c.image_id = i.id)
+		ORDER BY i.id
+		LIMIT $1`, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	images := make([]types.Images, 0)
+	for rows.Next() {
+		image, err := utils.ScanRowIntoImages(rows)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, *image)
+	}
+
+	return images, nil
+}
+
+func (s *Store) DeleteImage(id int) error {
+	_, err := s.db.Exec("DELETE FROM images WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// the image row and its link go in together so the sweep never sees a new image as an orphan
+func (s *Store) link(statement string, id int, image types.Images) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var imageId int
+	err = tx.QueryRow(
+		`INSERT INTO images (blob_key, thumbnail_key, content_type, width, height)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`,
+		image.BlobKey, image.ThumbnailKey, image.ContentType, image.Width, image.Height,
+	).Scan(&imageId)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(statement, id, imageId); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
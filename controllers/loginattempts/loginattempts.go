@@ -0,0 +1,76 @@
+package loginattempts
+
+import (
+	"database/sql"
+	"time"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// the remaining lock is worked out in sql so the database clock is the only one that matters
+func (s *Store) GetLoginLock(key string) (time.Duration, error) {
+	var seconds float64
+	err := s.db.QueryRow(
+		`SELECT GREATEST(EXTRACT(EPOCH FROM locked_until - CURRENT_TIMESTAMP), 0)::FLOAT
+		FROM login_attempts
+		WHERE attempt_key = $1`, key,
+	).Scan(&seconds)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+func (s *Store) RecordFailedLogin(key string, window time.Duration) (int, error) {
+	var failures int
+	err := s.db.QueryRow(
+		`INSERT INTO login_attempts (attempt_key, failures)
+		VALUES ($1, 1)
+		ON CONFLICT (attempt_key) DO UPDATE
+		SET failures = CASE
+				WHEN login_attempts.last_failed_at < CURRENT_TIMESTAMP - $2 * INTERVAL '1 second' THEN 1
+				ELSE login_attempts.failures + 1
+			END,
+			last_failed_at = CURRENT_TIMESTAMP
+		RETURNING failures`,
+		key, int64(window.Seconds()),
+	).Scan(&failures)
+	if err != nil {
+		return 0, err
+	}
+
+	return failures, nil
+}
+
+func (s *Store) LockLogin(key string, lockFor time.Duration) error {
+	_, err := s.db.Exec(
+		`UPDATE login_attempts
+		SET locked_until = CURRENT_TIMESTAMP + $2 * INTERVAL '1 second'
+		WHERE attempt_key = $1`,
+		key, int64(lockFor.Seconds()),
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) ClearLoginAttempts(key string) error {
+	_, err := s.db.Exec("DELETE FROM login_attempts WHERE attempt_key = $1", key)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package loginattempts
+
+import (
+	"sync"
+	"time"
+)
+
+// maxMemoryAttempts is when stale keys get swept, so addresses that fail once don't pile up forever
+const maxMemoryAttempts = 10000
+
+type memoryAttempt struct {
+	failures     int
+	lastFailedAt time.Time
+	lockedUntil  time.Time
+	window       time.Duration
+}
+
+// MemoryStore keeps counts in the process, for development and single instance deployments
+type MemoryStore struct {
+	mu       sync.Mutex
+	attempts map[string]*memoryAttempt
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{attempts: make(map[string]*memoryAttempt)}
+}
+
+func (s *MemoryStore) GetLoginLock(key string) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempt, ok := s.attempts[key]
+	if !ok {
+		return 0, nil
+	}
+
+	return max(time.Until(attempt.lockedUntil), 0), nil
+}
+
+func (s *MemoryStore) RecordFailedLogin(key string, window time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if len(s.attempts) >= maxMemoryAttempts {
+		s.sweep(now)
+	}
+
+	attempt, ok := s.attempts[key]
+	if !ok {
+		attempt = new(memoryAttempt)
+		s.attempts[key] = attempt
+	}
+
+	if attempt.lastFailedAt.Before(now.Add(-window)) {
+		attempt.failures = 0
+	}
+	attempt.failures++
+	attempt.lastFailedAt = now
+	attempt.window = window
+
+	return attempt.failures, nil
+}
+
+func (s *MemoryStore) LockLogin(key string, lockFor time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if attempt, ok := s.attempts[key]; ok {
+		attempt.lockedUntil = time.Now().Add(lockFor)
+	}
+
+	return nil
+}
+
+func (s *MemoryStore) ClearLoginAttempts(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.attempts, key)
+
+	return nil
+}
+
+// drops keys that aren't locked and whose failures are outside their window
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, attempt := range s.attempts {
+		if attempt.lockedUntil.Before(now) && attempt.lastFailedAt.Before(now.Add(-attempt.window)) {
+			delete(s.attempts, key)
+		}
+	}
+}
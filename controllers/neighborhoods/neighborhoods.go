@@ -15,8 +15,13 @@ func NewStore(db *sql.DB) *Store {
 	return &Store{db: db}
 }
 
-func (s *Store) GetNeighborhoods() ([]types.Neighborhoods, error) {
-	rows, err := s.db.Query("SELECT * FROM neighborhoods")
+func (s *Store) GetNeighborhoods(page types.Pagination) ([]types.Neighborhoods, error) {
+	rows, err := s.db.Query(
+		`SELECT * FROM neighborhoods
+		WHERE id > $1
+		ORDER BY id
+		LIMIT NULLIF($2, 0)`, page.After.Id, page.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -33,6 +38,23 @@ func (s *Store) GetNeighborhoods() ([]types.Neighborhoods, error) {
 	return neighborhoods, nil
 }
 
+func (s *Store) GetNeighborhoodById(id int) (*types.Neighborhoods, error) {
+	rows, err := s.db.Query("SELECT * FROM neighborhoods WHERE id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+
+	neighborhood := new(types.Neighborhoods)
+	for rows.Next() {
+		neighborhood, err = utils.ScanRowsIntoNeighborhood(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return neighborhood, nil
+}
+
 func (s *Store) CreateNeighborhood(neighborhood types.Neighborhoods) error {
 	_, err := s.db.Exec(
 		`INSERT INTO neighborhoods (neighborhood)
@@ -45,3 +67,55 @@ func (s *Store) CreateNeighborhood(neighborhood types.Neighborhoods) error {
 
 	return nil
 }
+
+/* MODERATORS */
+
+func (s *Store) GetModerators(neighborhoodId int) ([]types.NeighborhoodModerators, error) {
+	rows, err := s.db.Query(
+		`SELECT * FROM neighborhood_moderators
+		WHERE neighborhood_id = $1
+		ORDER BY id`, neighborhoodId,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	moderators := make([]types.NeighborhoodModerators, 0)
+	for rows.Next() {
+		moderator, err := utils.ScanRowIntoNeighborhoodModerators(rows)
+		if err != nil {
+			return nil, err
+		}
+		moderators = append(moderators, *moderator)
+	}
+
+	return moderators, nil
+}
+
+// adding a neighbor that already moderates the neighborhood does nothing
+func (s *Store) CreateModerator(moderator types.NeighborhoodModerators) error {
+	_, err := s.db.Exec(
+		`INSERT INTO neighborhood_moderators (neighborhood_id, neighbor_id)
+		VALUES ($1, $2)
+		ON CONFLICT (neighborhood_id, neighbor_id) DO NOTHING`,
+		moderator.NeighborhoodId, moderator.NeighborId,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) DeleteModerator(neighborhoodId int, neighborId int) error {
+	_, err := s.db.Exec(
+		`DELETE FROM neighborhood_moderators
+		WHERE neighborhood_id = $1 AND neighbor_id = $2`,
+		neighborhoodId, neighborId,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
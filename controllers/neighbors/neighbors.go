@@ -3,6 +3,8 @@ package neighbors
 import (
 	"database/sql"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/jamesdavidyu/neighborhost-service/cmd/model/types"
 	"github.com/jamesdavidyu/neighborhost-service/utils"
@@ -16,43 +18,30 @@ func NewStore(db *sql.DB) *Store {
 	return &Store{db: db}
 }
 
+// returns an empty neighbor when the email is not taken
 func (s *Store) GetNeighborWithEmail(email string) (*types.Neighbors, error) {
-	var neighbor types.Neighbors
-
 	rows, err := s.db.Query(
 		`SELECT * FROM neighbors
 		WHERE email = $1`,
 		email,
 	)
-	// need to handle errors better
 	if err != nil {
 		return nil, err
 	}
-	// defer rows.Close()
-
-	// if !rows.Next() {
-	// 	return nil, sql.ErrNoRows
-	// }
-
-	if err := rows.Scan(
-		&neighbor.Id,
-		&neighbor.Email,
-		&neighbor.Username,
-		&neighbor.Zipcode,
-		&neighbor.Password,
-		&neighbor.Verified,
-		&neighbor.NeighborhoodId,
-		&neighbor.CreatedAt,
-	); err != nil {
-		return nil, err
+
+	neighbor := new(types.Neighbors)
+	for rows.Next() {
+		neighbor, err = utils.ScanRowIntoNeighbor(rows)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	return &neighbor, nil
+	return neighbor, nil
 }
 
+// returns an empty neighbor when the username is not taken
 func (s *Store) GetNeighborWithUsername(username string) (*types.Neighbors, error) {
-	var neighbor types.Neighbors
-
 	rows, err := s.db.Query(
 		`SELECT * FROM neighbors
 		WHERE username = $1`,
@@ -61,26 +50,16 @@ func (s *Store) GetNeighborWithUsername(username string) (*types.Neighbors, erro
 	if err != nil {
 		return nil, err
 	}
-	// defer rows.Close()
-
-	// if !rows.Next() {
-	// 	return nil, sql.ErrNoRows
-	// }
-
-	if err := rows.Scan(
-		&neighbor.Id,
-		&neighbor.Email,
-		&neighbor.Username,
-		&neighbor.Zipcode,
-		&neighbor.Password,
-		&neighbor.Verified,
-		&neighbor.NeighborhoodId,
-		&neighbor.CreatedAt,
-	); err != nil {
-		return nil, err
+
+	neighbor := new(types.Neighbors)
+	for rows.Next() {
+		neighbor, err = utils.ScanRowIntoNeighbor(rows)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	return &neighbor, nil
+	return neighbor, nil
 }
 
 func (s *Store) GetNeighborWithEmailOrUsername(emailOrUsername string) (*types.Neighbors, error) {
@@ -129,6 +108,25 @@ func (s *Store) GetNeighborById(id int) (*types.Neighbors, error) {
 	return neighbor, nil
 }
 
+// the neighborhood of the neighbor's latest address, home addresses first. 0 when they have none or it isn't assigned yet
+func (s *Store) GetNeighborhoodIdById(id int) (int, error) {
+	var neighborhoodId int
+	err := s.db.QueryRow(
+		`SELECT COALESCE(NULLIF(neighborhood_id, $2), 0) FROM addresses
+		WHERE neighbor_id = $1
+		ORDER BY LOWER(type) = 'home' DESC, recorded_at DESC, id DESC
+		LIMIT 1`, id, utils.UnassignedNeighborhoodId,
+	).Scan(&neighborhoodId)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return neighborhoodId, nil
+}
+
 func (s *Store) UpdateZipcodeWithId(neighbor types.Neighbors) error {
 	_, err := s.db.Exec(
 		`UPDATE neighbors
@@ -143,6 +141,21 @@ func (s *Store) UpdateZipcodeWithId(neighbor types.Neighbors) error {
 	return nil
 }
 
+// changing the email is expected to come with verified set back to false
+func (s *Store) UpdateAccountWithId(neighbor types.Neighbors) error {
+	_, err := s.db.Exec(
+		`UPDATE neighbors
+		SET email = $1, username = $2, zipcode = $3, verified = $4
+		WHERE id = $5`,
+		neighbor.Email, neighbor.Username, neighbor.Zipcode, neighbor.Verified, neighbor.Id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (s *Store) UpdateVerifiedWithId(neighbor types.Neighbors) error {
 	_, err := s.db.Exec(
 		`UPDATE neighbors
@@ -157,12 +170,13 @@ func (s *Store) UpdateVerifiedWithId(neighbor types.Neighbors) error {
 	return nil
 }
 
-func (s *Store) UpdatePasswordWithId(neighbor types.Neighbors) error {
+// the role is read on every request, so the change applies without logging the neighbor out
+func (s *Store) UpdateRoleWithId(neighbor types.Neighbors) error {
 	_, err := s.db.Exec(
 		`UPDATE neighbors
-		SET password = $1
+		SET role = $1
 		WHERE id = $2`,
-		neighbor.Password, neighbor.Id,
+		neighbor.Role, neighbor.Id,
 	)
 	if err != nil {
 		return err
@@ -171,6 +185,52 @@ func (s *Store) UpdatePasswordWithId(neighbor types.Neighbors) error {
 	return nil
 }
 
+func (s *Store) IsModerator(neighborId int, neighborhoodId int) (bool, error) {
+	var isModerator bool
+	err := s.db.QueryRow(
+		`SELECT EXISTS (
+			SELECT 1 FROM neighborhood_moderators
+			WHERE neighbor_id = $1 AND neighborhood_id = $2
+		)`, neighborId, neighborhoodId,
+	).Scan(&isModerator)
+	if err != nil {
+		return false, err
+	}
+
+	return isModerator, nil
+}
+
+// tokens and sessions from before the change stop working
+func (s *Store) UpdatePasswordWithId(neighbor types.Neighbors) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		`UPDATE neighbors
+		SET password = $1,
+		token_version = token_version + 1
+		WHERE id = $2`,
+		neighbor.Password, neighbor.Id,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		`UPDATE sessions
+		SET revoked = TRUE
+		WHERE neighbor_id = $1`, neighbor.Id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
 func (s *Store) CreateNeighbor(neighbor types.Neighbors) error {
 	_, err := s.db.Exec(
 		`INSERT INTO neighbors (email, username, zipcode, password, ip)
@@ -187,3 +247,291 @@ func (s *Store) CreateNeighbor(neighbor types.Neighbors) error {
 
 	return nil
 }
+
+/* SESSIONS */
+
+func (s *Store) CreateSession(session types.Sessions, expiresIn time.Duration) error {
+	_, err := s.db.Exec(
+		`INSERT INTO sessions (neighbor_id, jti, refresh_token_hash, expires_at)
+		VALUES ($1, $2, $3, CURRENT_TIMESTAMP + $4 * INTERVAL '1 second')`,
+		session.NeighborId,
+		session.Jti,
+		session.RefreshTokenHash,
+		int(expiresIn.Seconds()),
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) GetSessionByJti(jti string) (*types.Sessions, error) {
+	rows, err := s.db.Query("SELECT * FROM sessions WHERE jti = $1", jti)
+	if err != nil {
+		return nil, err
+	}
+
+	session := new(types.Sessions)
+	for rows.Next() {
+		session, err = utils.ScanRowIntoSessions(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return session, nil
+}
+
+// swaps in the new refresh token and jti, returns an empty session when the refresh token isn't usable.
+// A refresh token that was already rotated out means it was copied, so that session is revoked.
+func (s *Store) RotateSession(refreshTokenHash string, session types.Sessions, expiresIn time.Duration) (*types.Sessions, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(
+		`UPDATE sessions
+		SET jti = $1,
+		refresh_token_hash = $2,
+		previous_refresh_token_hash = refresh_token_hash,
+		expires_at = CURRENT_TIMESTAMP + $3 * INTERVAL '1 second',
+		last_used_at = CURRENT_TIMESTAMP
+		WHERE refresh_token_hash = $4
+		AND revoked = FALSE
+		AND expires_at > CURRENT_TIMESTAMP
+		RETURNING *`,
+		session.Jti,
+		session.RefreshTokenHash,
+		int(expiresIn.Seconds()),
+		refreshTokenHash,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rotated := new(types.Sessions)
+	for rows.Next() {
+		rotated, err = utils.ScanRowIntoSessions(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+	rows.Close()
+
+	if rotated.Id == 0 {
+		_, err = tx.Exec(
+			`UPDATE sessions
+			SET revoked = TRUE
+			WHERE previous_refresh_token_hash = $1`, refreshTokenHash,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return rotated, nil
+}
+
+func (s *Store) RevokeSession(jti string) error {
+	_, err := s.db.Exec(
+		`UPDATE sessions
+		SET revoked = TRUE
+		WHERE jti = $1`, jti,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) RevokeSessionsByNeighborId(neighborId int) error {
+	_, err := s.db.Exec(
+		`UPDATE sessions
+		SET revoked = TRUE
+		WHERE neighbor_id = $1`, neighborId,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+/* LOGINS */
+
+// user agents are cut to fit the column rather than failing the login
+func (s *Store) CreateLogin(login types.NeighborLogins) error {
+	userAgent := login.UserAgent
+	if len(userAgent) > 255 {
+		userAgent = strings.ToValidUTF8(userAgent[:255], "")
+	}
+
+	_, err := s.db.Exec(
+		`INSERT INTO neighbor_logins (neighbor_id, ip, user_agent)
+		VALUES ($1, $2, $3)`,
+		login.NeighborId, login.Ip, userAgent,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) GetLoginsByNeighborId(neighborId int, page types.Pagination) ([]types.NeighborLogins, error) {
+	rows, err := s.db.Query(
+		`SELECT * FROM neighbor_logins
+		WHERE neighbor_id = $1
+		AND ($2 = 0 OR id < $2)
+		ORDER BY id DESC
+		LIMIT NULLIF($3, 0)`, neighborId, page.After.Id, page.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	logins := make([]types.NeighborLogins, 0)
+	for rows.Next() {
+		login, err := utils.ScanRowIntoNeighborLogins(rows)
+		if err != nil {
+			return nil, err
+		}
+		logins = append(logins, *login)
+	}
+
+	return logins, nil
+}
+
+/* DELETIONS */
+
+// the neighbor is logged out everywhere right away, their data stays until the grace period is over
+func (s *Store) ScheduleDeletion(neighborId int, gracePeriod time.Duration) (*types.AccountDeletions, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	deletion := new(types.AccountDeletions)
+	err = tx.QueryRow(
+		`INSERT INTO account_deletions (neighbor_id, purge_after)
+		VALUES ($1, CURRENT_TIMESTAMP + $2 * INTERVAL '1 second')
+		ON CONFLICT (neighbor_id) DO UPDATE
+		SET neighbor_id = EXCLUDED.neighbor_id
+		RETURNING *`,
+		neighborId, int64(gracePeriod.Seconds()),
+	).Scan(&deletion.NeighborId, &deletion.RequestedAt, &deletion.PurgeAfter)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(
+		`UPDATE neighbors
+		SET token_version = token_version + 1
+		WHERE id = $1`, neighborId,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(
+		`UPDATE sessions
+		SET revoked = TRUE
+		WHERE neighbor_id = $1`, neighborId,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return deletion, tx.Commit()
+}
+
+// false when no deletion was scheduled
+func (s *Store) CancelDeletion(neighborId int) (bool, error) {
+	result, err := s.db.Exec("DELETE FROM account_deletions WHERE neighbor_id = $1", neighborId)
+	if err != nil {
+		return false, err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return deleted == 1, nil
+}
+
+// each neighbor is removed in its own transaction, tables that don't cascade from neighbors are cleared first
+func (s *Store) PurgeDueDeletions() (int, error) {
+	rows, err := s.db.Query(
+		`SELECT neighbor_id FROM account_deletions
+		WHERE purge_after <= CURRENT_TIMESTAMP`,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	neighborIds := make([]int, 0)
+	for rows.Next() {
+		var neighborId int
+		if err := rows.Scan(&neighborId); err != nil {
+			return 0, err
+		}
+		neighborIds = append(neighborIds, neighborId)
+	}
+
+	for i, neighborId := range neighborIds {
+		if err := s.purgeNeighbor(neighborId); err != nil {
+			return i, err
+		}
+	}
+
+	return len(neighborIds), nil
+}
+
+func (s *Store) purgeNeighbor(neighborId int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// the neighbor may have logged back in since the deletions were listed
+	result, err := tx.Exec(
+		`DELETE FROM account_deletions
+		WHERE neighbor_id = $1 AND purge_after <= CURRENT_TIMESTAMP`, neighborId,
+	)
+	if err != nil {
+		return err
+	}
+
+	if deleted, err := result.RowsAffected(); err != nil || deleted == 0 {
+		return err
+	}
+
+	statements := []string{
+		"DELETE FROM friends WHERE neighbor_id = $1 OR neighbors_friend_id = $1",
+		"DELETE FROM friend_requests WHERE neighbor_id = $1 OR requested_friend_id = $1",
+		// rsvps and invites go with the events
+		`DELETE FROM events
+		WHERE host_id = $1
+		OR address_id IN (SELECT id FROM addresses WHERE neighbor_id = $1)`,
+		"DELETE FROM addresses WHERE neighbor_id = $1",
+		"DELETE FROM neighbors WHERE id = $1",
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement, neighborId); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,85 @@
+package notifications
+
+import (
+	"database/sql"
+
+	"github.com/jamesdavidyu/neighborhost-service/cmd/model/types"
+	"github.com/jamesdavidyu/neighborhost-service/utils"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// notifies everyone who rsvped to or was invited to the event, apart from the host and anyone who declined
+func (s *Store) CreateEventNotifications(notification types.Notifications) error {
+	_, err := s.db.Exec(
+		`INSERT INTO notifications (neighbor_id, event_id, type, message)
+		SELECT DISTINCT recipients.neighbor_id, $1::int, $2::varchar, $3::varchar
+		FROM (
+			SELECT neighbor_id FROM event_rsvps
+			WHERE event_id = $1
+			AND status <> 'declined'
+			UNION
+			SELECT invited_neighbor_id FROM event_invites
+			WHERE event_id = $1
+			AND status <> 'declined'
+		) recipients
+		JOIN events e ON e.id = $1
+		WHERE recipients.neighbor_id <> e.host_id`,
+		notification.EventId,
+		notification.Type,
+		notification.Message,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// newest first, ids follow creation order so they double as the sort key
+func (s *Store) GetNotificationsByNeighborId(neighborId int, page types.Pagination) ([]types.Notifications, error) {
+	rows, err := s.db.Query(
+		`SELECT * FROM notifications
+		WHERE neighbor_id = $1
+		AND ($2 = 0 OR id < $2)
+		ORDER BY id DESC
+		LIMIT NULLIF($3, 0)`, neighborId, page.After.Id, page.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	notifications := make([]types.Notifications, 0)
+	for rows.Next() {
+		notification, err := utils.ScanRowIntoNotifications(rows)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, *notification)
+	}
+
+	return notifications, nil
+}
+
+func (s *Store) UpdateNotificationRead(notification types.Notifications) error {
+	_, err := s.db.Exec(
+		`UPDATE notifications
+		SET read = $1
+		WHERE id = $2
+		AND neighbor_id = $3`,
+		notification.Read,
+		notification.Id,
+		notification.NeighborId,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
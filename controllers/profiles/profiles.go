@@ -15,34 +15,77 @@ func NewStore(db *sql.DB) *Store {
 	return &Store{db: db}
 }
 
+// every attribute is optional, so the profile hangs off the neighbor and missing rows come back empty and private,
+// attributes are append only and the latest row of each is the current value
 func (s *Store) GetProfileByNeighborId(neighborId int) (*types.Profiles, error) {
 	rows, err := s.db.Query(
 		`SELECT 
-			b.neighbor_id,
-			b.bio,
+			n.id,
+			COALESCE(b.bio, ''),
 			dob.date_of_birth,
-			dob.date_of_birth_public,
-			g.gender,
-			g.gender_public,
-			r.race,
-			r.race_public,
-			e.ethnicity,
-			e.ethnicity_public,
-			rs.relationship_status,
-			rs.relationship_status_public,
-			re.religion,
-			re.religion_public,
-			p.politics,
-			p.politics_public
-		FROM bios b
-		JOIN dates_of_birth dob ON dob.neighbor_id = b.neighbor_id
-		JOIN genders g ON g.neighbor_id = b.neighbor_id
-		JOIN races r ON r.neighbor_id = b.neighbor_id
-		JOIN ethnicities e ON e.neighbor_id = b.neighbor_id
-		JOIN relationship_statuses rs ON rs.neighbor_id = b.neighbor_id
-		JOIN religions re ON re.neighbor_id = b.neighbor_id
-		JOIN politics p On p.neighbor_id = b.neighbor_id
-		WHERE b.neighbor_id = $1`, neighborId,
+			COALESCE(dob.visibility, 'private'),
+			COALESCE(g.gender, ''),
+			COALESCE(g.visibility, 'private'),
+			COALESCE(r.race, ''),
+			COALESCE(r.visibility, 'private'),
+			COALESCE(e.ethnicity, ''),
+			COALESCE(e.visibility, 'private'),
+			COALESCE(rs.relationship_status, ''),
+			COALESCE(rs.visibility, 'private'),
+			COALESCE(re.religion, ''),
+			COALESCE(re.visibility, 'private'),
+			COALESCE(p.politics, ''),
+			COALESCE(p.visibility, 'private')
+		FROM neighbors n
+		LEFT JOIN LATERAL (
+			SELECT bio FROM bios
+			WHERE neighbor_id = n.id
+			ORDER BY created_at DESC, id DESC
+			LIMIT 1
+		) b ON TRUE
+		LEFT JOIN LATERAL (
+			SELECT date_of_birth, visibility FROM dates_of_birth
+			WHERE neighbor_id = n.id
+			ORDER BY recorded_at DESC, id DESC
+			LIMIT 1
+		) dob ON TRUE
+		LEFT JOIN LATERAL (
+			SELECT gender, visibility FROM genders
+			WHERE neighbor_id = n.id
+			ORDER BY recorded_at DESC, id DESC
+			LIMIT 1
+		) g ON TRUE
+		LEFT JOIN LATERAL (
+			SELECT race, visibility FROM races
+			WHERE neighbor_id = n.id
+			ORDER BY recorded_at DESC, id DESC
+			LIMIT 1
+		) r ON TRUE
+		LEFT JOIN LATERAL (
+			SELECT ethnicity, visibility FROM ethnicities
+			WHERE neighbor_id = n.id
+			ORDER BY recorded_at DESC, id DESC
+			LIMIT 1
+		) e ON TRUE
+		LEFT JOIN LATERAL (
+			SELECT relationship_status, visibility FROM relationship_statuses
+			WHERE neighbor_id = n.id
+			ORDER BY recorded_at DESC, id DESC
+			LIMIT 1
+		) rs ON TRUE
+		LEFT JOIN LATERAL (
+			SELECT religion, visibility FROM religions
+			WHERE neighbor_id = n.id
+			ORDER BY recorded_at DESC, id DESC
+			LIMIT 1
+		) re ON TRUE
+		LEFT JOIN LATERAL (
+			SELECT politics, visibility FROM politics
+			WHERE neighbor_id = n.id
+			ORDER BY recorded_at DESC, id DESC
+			LIMIT 1
+		) p ON TRUE
+		WHERE n.id = $1`, neighborId,
 	)
 	if err != nil {
 		return nil, err
@@ -56,5 +99,369 @@ func (s *Store) GetProfileByNeighborId(neighborId int) (*types.Profiles, error)
 		}
 	}
 
+	if profile.NeighborId == 0 {
+		return profile, nil
+	}
+
+	if profile.Education, err = s.GetEducationByNeighborId(neighborId); err != nil {
+		return nil, err
+	}
+
+	if profile.Occupations, err = s.GetOccupationsByNeighborId(neighborId); err != nil {
+		return nil, err
+	}
+
+	if profile.Interests, err = s.GetInterestsByNeighborId(neighborId); err != nil {
+		return nil, err
+	}
+
 	return profile, nil
 }
+
+// saving the bio it already has doesn't add to its history
+func (s *Store) CreateBio(bio types.Bios) error {
+	_, err := s.db.Exec(
+		`INSERT INTO bios (neighbor_id, bio)
+		SELECT $1, $2
+		WHERE NOT EXISTS (
+			SELECT 1 FROM (
+				SELECT bio FROM bios
+				WHERE neighbor_id = $1
+				ORDER BY created_at DESC, id DESC
+				LIMIT 1
+			) latest
+			WHERE latest.bio = $2
+		)`,
+		bio.NeighborId, bio.Bio,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) CreateDateOfBirth(dateOfBirth types.DatesOfBirth) error {
+	return s.createAttribute("dates_of_birth", "date_of_birth", dateOfBirth.NeighborId, dateOfBirth.DateOfBirth, dateOfBirth.Visibility)
+}
+
+func (s *Store) CreateGender(gender types.Genders) error {
+	return s.createAttribute("genders", "gender", gender.NeighborId, gender.Gender, gender.Visibility)
+}
+
+func (s *Store) CreateRace(race types.Races) error {
+	return s.createAttribute("races", "race", race.NeighborId, race.Race, race.Visibility)
+}
+
+func (s *Store) CreateEthnicity(ethnicity types.Ethnicities) error {
+	return s.createAttribute("ethnicities", "ethnicity", ethnicity.NeighborId, ethnicity.Ethnicity, ethnicity.Visibility)
+}
+
+func (s *Store) CreateRelationshipStatus(relationshipStatus types.RelationshipStatuses) error {
+	return s.createAttribute("relationship_statuses", "relationship_status", relationshipStatus.NeighborId, relationshipStatus.RelationshipStatus, relationshipStatus.Visibility)
+}
+
+func (s *Store) CreateReligion(religion types.Religions) error {
+	return s.createAttribute("religions", "religion", religion.NeighborId, religion.Religion, religion.Visibility)
+}
+
+func (s *Store) CreatePolitics(politics types.Politics) error {
+	return s.createAttribute("politics", "politics", politics.NeighborId, politics.Politics, politics.Visibility)
+}
+
+// attributes are append only, a new row is only added when the value or its visibility changed.
+// table and column only ever come from the constants above, never from a request
+func (s *Store) createAttribute(table string, column string, neighborId int, value any, visibility string) error {
+	_, err := s.db.Exec(
+		`INSERT INTO `+table+` (neighbor_id, `+column+`, visibility)
+		SELECT $1, $2, $3
+		WHERE NOT EXISTS (
+			SELECT 1 FROM (
+				SELECT `+column+`, visibility FROM `+table+`
+				WHERE neighbor_id = $1
+				ORDER BY recorded_at DESC, id DESC
+				LIMIT 1
+			) latest
+			WHERE latest.`+column+` = $2 AND latest.visibility = $3
+		)`,
+		neighborId, value, visibility,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// every value each attribute has had, newest first, the bio has no visibility
+func (s *Store) GetProfileHistoryByNeighborId(neighborId int) ([]types.ProfileHistory, error) {
+	rows, err := s.db.Query(
+		`SELECT 'bio', bio, '', created_at FROM bios WHERE neighbor_id = $1
+		UNION ALL
+		SELECT 'dateOfBirth', TO_CHAR(date_of_birth, 'YYYY-MM-DD'), visibility, recorded_at FROM dates_of_birth WHERE neighbor_id = $1
+		UNION ALL
+		SELECT 'gender', gender, visibility, recorded_at FROM genders WHERE neighbor_id = $1
+		UNION ALL
+		SELECT 'race', race, visibility, recorded_at FROM races WHERE neighbor_id = $1
+		UNION ALL
+		SELECT 'ethnicity', ethnicity, visibility, recorded_at FROM ethnicities WHERE neighbor_id = $1
+		UNION ALL
+		SELECT 'relationshipStatus', relationship_status, visibility, recorded_at FROM relationship_statuses WHERE neighbor_id = $1
+		UNION ALL
+		SELECT 'religion', religion, visibility, recorded_at FROM religions WHERE neighbor_id = $1
+		UNION ALL
+		SELECT 'politics', politics, visibility, recorded_at FROM politics WHERE neighbor_id = $1
+		ORDER BY 4 DESC, 1`, neighborId,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]types.ProfileHistory, 0)
+	for rows.Next() {
+		change, err := utils.ScanRowIntoProfileHistory(rows)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, *change)
+	}
+
+	return history, nil
+}
+
+func (s *Store) GetEducationByNeighborId(neighborId int) ([]types.Education, error) {
+	rows, err := s.db.Query(
+		`SELECT * FROM education
+		WHERE neighbor_id = $1
+		ORDER BY start_date DESC, id DESC`, neighborId,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	education := make([]types.Education, 0)
+	for rows.Next() {
+		school, err := utils.ScanRowIntoEducation(rows)
+		if err != nil {
+			return nil, err
+		}
+		education = append(education, *school)
+	}
+
+	return education, nil
+}
+
+func (s *Store) CreateEducation(education types.Education) error {
+	_, err := s.db.Exec(
+		`INSERT INTO education (neighbor_id, school, degree, start_date, end_date, visibility)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		education.NeighborId, education.School, education.Degree, education.Start, education.End, education.Visibility,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// only the neighbor's own rows match, false means there was nothing to update
+func (s *Store) UpdateEducation(education types.Education) (bool, error) {
+	result, err := s.db.Exec(
+		`UPDATE education
+		SET school = $1, degree = $2, start_date = $3, end_date = $4, visibility = $5, recorded_at = CURRENT_TIMESTAMP
+		WHERE id = $6 AND neighbor_id = $7`,
+		education.School, education.Degree, education.Start, education.End, education.Visibility, education.Id, education.NeighborId,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return updated == 1, nil
+}
+
+func (s *Store) DeleteEducation(id int, neighborId int) (bool, error) {
+	result, err := s.db.Exec(
+		`DELETE FROM education
+		WHERE id = $1 AND neighbor_id = $2`,
+		id, neighborId,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return deleted == 1, nil
+}
+
+func (s *Store) GetOccupationsByNeighborId(neighborId int) ([]types.Occupations, error) {
+	rows, err := s.db.Query(
+		`SELECT * FROM occupations
+		WHERE neighbor_id = $1
+		ORDER BY start_date DESC, id DESC`, neighborId,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	occupations := make([]types.Occupations, 0)
+	for rows.Next() {
+		occupation, err := utils.ScanRowIntoOccupations(rows)
+		if err != nil {
+			return nil, err
+		}
+		occupations = append(occupations, *occupation)
+	}
+
+	return occupations, nil
+}
+
+func (s *Store) CreateOccupation(occupation types.Occupations) error {
+	_, err := s.db.Exec(
+		`INSERT INTO occupations (neighbor_id, role, employer, start_date, end_date, visibility)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		occupation.NeighborId, occupation.Role, occupation.Employer, occupation.Start, occupation.End, occupation.Visibility,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) UpdateOccupation(occupation types.Occupations) (bool, error) {
+	result, err := s.db.Exec(
+		`UPDATE occupations
+		SET role = $1, employer = $2, start_date = $3, end_date = $4, visibility = $5, recorded_at = CURRENT_TIMESTAMP
+		WHERE id = $6 AND neighbor_id = $7`,
+		occupation.Role, occupation.Employer, occupation.Start, occupation.End, occupation.Visibility, occupation.Id, occupation.NeighborId,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return updated == 1, nil
+}
+
+func (s *Store) DeleteOccupation(id int, neighborId int) (bool, error) {
+	result, err := s.db.Exec(
+		`DELETE FROM occupations
+		WHERE id = $1 AND neighbor_id = $2`,
+		id, neighborId,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return deleted == 1, nil
+}
+
+func (s *Store) GetInterestsByNeighborId(neighborId int) ([]types.Interests, error) {
+	rows, err := s.db.Query(
+		`SELECT i.id, i.neighbor_id, i.interest_id, t.interest, t.category, i.visibility, i.recorded_at
+		FROM interests i
+		JOIN interest_taxonomy t ON t.id = i.interest_id
+		WHERE i.neighbor_id = $1
+		ORDER BY t.category, t.interest`, neighborId,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	interests := make([]types.Interests, 0)
+	for rows.Next() {
+		interest, err := utils.ScanRowIntoInterests(rows)
+		if err != nil {
+			return nil, err
+		}
+		interests = append(interests, *interest)
+	}
+
+	return interests, nil
+}
+
+// adding an interest the neighbor already has only updates its visibility
+func (s *Store) CreateInterest(interest types.Interests) error {
+	_, err := s.db.Exec(
+		`INSERT INTO interests (neighbor_id, interest_id, visibility)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (neighbor_id, interest_id) DO UPDATE
+		SET visibility = EXCLUDED.visibility`,
+		interest.NeighborId, interest.InterestId, interest.Visibility,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) DeleteInterest(neighborId int, interestId int) (bool, error) {
+	result, err := s.db.Exec(
+		`DELETE FROM interests
+		WHERE neighbor_id = $1 AND interest_id = $2`,
+		neighborId, interestId,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return deleted == 1, nil
+}
+
+func (s *Store) GetInterestTaxonomy() ([]types.InterestTaxonomy, error) {
+	rows, err := s.db.Query(
+		`SELECT * FROM interest_taxonomy
+		ORDER BY category, interest`,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	taxonomy := make([]types.InterestTaxonomy, 0)
+	for rows.Next() {
+		interest, err := utils.ScanRowIntoInterestTaxonomy(rows)
+		if err != nil {
+			return nil, err
+		}
+		taxonomy = append(taxonomy, *interest)
+	}
+
+	return taxonomy, nil
+}
+
+func (s *Store) CreateInterestTaxonomy(interest types.InterestTaxonomy) error {
+	_, err := s.db.Exec(
+		`INSERT INTO interest_taxonomy (interest, category)
+		VALUES ($1, $2)`,
+		interest.Interest, interest.Category,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package tokens
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/jamesdavidyu/neighborhost-service/cmd/model/types"
+	"github.com/jamesdavidyu/neighborhost-service/utils"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// a new token replaces any the neighbor hasn't used yet for the same purpose
+func (s *Store) CreateNeighborToken(token types.NeighborTokens, expiresIn time.Duration) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		`UPDATE neighbor_tokens
+		SET used = TRUE
+		WHERE neighbor_id = $1
+		AND purpose = $2
+		AND used = FALSE`,
+		token.NeighborId,
+		token.Purpose,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO neighbor_tokens (neighbor_id, purpose, token_hash, expires_at)
+		VALUES ($1, $2, $3, CURRENT_TIMESTAMP + $4 * INTERVAL '1 second')`,
+		token.NeighborId,
+		token.Purpose,
+		token.TokenHash,
+		int(expiresIn.Seconds()),
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// marks the token used in the same statement that checks it, so it can only be redeemed once.
+// Returns an empty token when it doesn't exist, expired or was already used.
+func (s *Store) ConsumeNeighborToken(purpose string, tokenHash string) (*types.NeighborTokens, error) {
+	rows, err := s.db.Query(
+		`UPDATE neighbor_tokens
+		SET used = TRUE
+		WHERE purpose = $1
+		AND token_hash = $2
+		AND used = FALSE
+		AND expires_at > CURRENT_TIMESTAMP
+		RETURNING *`, purpose, tokenHash,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	token := new(types.NeighborTokens)
+	for rows.Next() {
+		token, err = utils.ScanRowIntoNeighborTokens(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return token, nil
+}
@@ -0,0 +1,149 @@
+package twofactors
+
+import (
+	"database/sql"
+
+	"github.com/jamesdavidyu/neighborhost-service/cmd/model/types"
+	"github.com/jamesdavidyu/neighborhost-service/utils"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) GetTwoFactorByNeighborId(neighborId int) (*types.TwoFactors, error) {
+	rows, err := s.db.Query("SELECT * FROM two_factors WHERE neighbor_id = $1", neighborId)
+	if err != nil {
+		return nil, err
+	}
+
+	twoFactor := new(types.TwoFactors)
+	for rows.Next() {
+		twoFactor, err = utils.ScanRowIntoTwoFactors(rows)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return twoFactor, nil
+}
+
+// setting up again replaces a secret that was never confirmed
+func (s *Store) CreateTwoFactor(twoFactor types.TwoFactors) error {
+	_, err := s.db.Exec(
+		`INSERT INTO two_factors (neighbor_id, secret)
+		VALUES ($1, $2)
+		ON CONFLICT (neighbor_id) DO UPDATE
+		SET secret = EXCLUDED.secret,
+			enabled = FALSE,
+			last_used_step = 0,
+			created_at = CURRENT_TIMESTAMP
+		WHERE two_factors.enabled = FALSE`,
+		twoFactor.NeighborId, twoFactor.Secret,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// recovery codes from an earlier setup are replaced
+func (s *Store) EnableTwoFactor(neighborId int, recoveryCodeHashes []string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		`UPDATE two_factors
+		SET enabled = TRUE
+		WHERE neighbor_id = $1`,
+		neighborId,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM recovery_codes WHERE neighbor_id = $1", neighborId)
+	if err != nil {
+		return err
+	}
+
+	for _, codeHash := range recoveryCodeHashes {
+		_, err = tx.Exec(
+			`INSERT INTO recovery_codes (neighbor_id, code_hash)
+			VALUES ($1, $2)`,
+			neighborId, codeHash,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// false when the step, or a later one, was already used
+func (s *Store) UseTwoFactorStep(neighborId int, step int64) (bool, error) {
+	result, err := s.db.Exec(
+		`UPDATE two_factors
+		SET last_used_step = $2
+		WHERE neighbor_id = $1 AND last_used_step < $2`,
+		neighborId, step,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return updated == 1, nil
+}
+
+func (s *Store) UseRecoveryCode(neighborId int, codeHash string) (bool, error) {
+	result, err := s.db.Exec(
+		`UPDATE recovery_codes
+		SET used = TRUE
+		WHERE neighbor_id = $1 AND code_hash = $2 AND used = FALSE`,
+		neighborId, codeHash,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return updated == 1, nil
+}
+
+func (s *Store) DeleteTwoFactor(neighborId int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM recovery_codes WHERE neighbor_id = $1", neighborId)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM two_factors WHERE neighbor_id = $1", neighborId)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -6,19 +6,36 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/jamesdavidyu/neighborhost-service/cmd/model/types"
+	"github.com/jamesdavidyu/neighborhost-service/config"
 	addressControllers "github.com/jamesdavidyu/neighborhost-service/controllers/addresses"
+	blobControllers "github.com/jamesdavidyu/neighborhost-service/controllers/blobs"
+	calendarControllers "github.com/jamesdavidyu/neighborhost-service/controllers/calendars"
 	eventControllers "github.com/jamesdavidyu/neighborhost-service/controllers/events"
 	friendControllers "github.com/jamesdavidyu/neighborhost-service/controllers/friends"
+	imageControllers "github.com/jamesdavidyu/neighborhost-service/controllers/images"
+	loginAttemptControllers "github.com/jamesdavidyu/neighborhost-service/controllers/loginattempts"
 	neighborhoodControllers "github.com/jamesdavidyu/neighborhost-service/controllers/neighborhoods"
 	neighborControllers "github.com/jamesdavidyu/neighborhost-service/controllers/neighbors"
+	notificationControllers "github.com/jamesdavidyu/neighborhost-service/controllers/notifications"
+	profileControllers "github.com/jamesdavidyu/neighborhost-service/controllers/profiles"
+	tokenControllers "github.com/jamesdavidyu/neighborhost-service/controllers/tokens"
+	twoFactorControllers "github.com/jamesdavidyu/neighborhost-service/controllers/twofactors"
 	"github.com/jamesdavidyu/neighborhost-service/controllers/zipcodes"
 	addressServices "github.com/jamesdavidyu/neighborhost-service/services/addresses"
+	calendarServices "github.com/jamesdavidyu/neighborhost-service/services/calendar"
 	eventServices "github.com/jamesdavidyu/neighborhost-service/services/events"
 	friendServices "github.com/jamesdavidyu/neighborhost-service/services/friends"
+	imageServices "github.com/jamesdavidyu/neighborhost-service/services/images"
+	"github.com/jamesdavidyu/neighborhost-service/services/mail"
+	meServices "github.com/jamesdavidyu/neighborhost-service/services/me"
 	neighborhoodServices "github.com/jamesdavidyu/neighborhost-service/services/neighborhoods"
 	neighborServices "github.com/jamesdavidyu/neighborhost-service/services/neighbors"
+	notificationServices "github.com/jamesdavidyu/neighborhost-service/services/notifications"
+	profileServices "github.com/jamesdavidyu/neighborhost-service/services/profiles"
 	"github.com/jamesdavidyu/neighborhost-service/utils"
 )
 
@@ -44,7 +61,23 @@ func (s *APIServer) Run() error {
 	zipcodeStore := zipcodes.NewStore(s.db)
 
 	neighborStore := neighborControllers.NewStore(s.db)
-	neighborHandler := neighborServices.NewHandler(neighborStore)
+	tokenStore := tokenControllers.NewStore(s.db)
+	var loginAttemptStore types.LoginAttemptStore = loginAttemptControllers.NewStore(s.db)
+	if config.Envs.LoginAttemptStore == "memory" {
+		loginAttemptStore = loginAttemptControllers.NewMemoryStore()
+	}
+	twoFactorStore := twoFactorControllers.NewStore(s.db)
+	mailer := mail.NewMailer()
+	var blobStore types.BlobStore = blobControllers.NewLocalStore(config.Envs.BlobDir)
+	if config.Envs.BlobStore == "s3" {
+		s3Store, err := blobControllers.NewS3Store(config.Envs.S3Endpoint, config.Envs.S3Region, config.Envs.S3Bucket, config.Envs.S3AccessKeyId, config.Envs.S3SecretAccessKey)
+		if err != nil {
+			return err
+		}
+		blobStore = s3Store
+	}
+	imageStore := imageControllers.NewStore(s.db)
+	neighborHandler := neighborServices.NewHandler(neighborStore, tokenStore, loginAttemptStore, twoFactorStore, mailer)
 	neighborHandler.RegisterRoutes(subrouter)
 
 	neighborhoodStore := neighborhoodControllers.NewStore(s.db)
@@ -55,14 +88,31 @@ func (s *APIServer) Run() error {
 	addressHandler := addressServices.NewHandler(addressStore, neighborStore, zipcodeStore)
 	addressHandler.RegisterRoutes(subrouter)
 
-	eventStore := eventControllers.NewStore(s.db)
-	eventHandler := eventServices.NewHandler(eventStore, neighborStore, zipcodeStore, addressStore)
-	eventHandler.RegisterRoutes(subrouter)
+	notificationStore := notificationControllers.NewStore(s.db)
+	notificationHandler := notificationServices.NewHandler(notificationStore, neighborStore)
+	notificationHandler.RegisterRoutes(subrouter)
 
 	friendStore := friendControllers.NewStore(s.db)
 	friendHandler := friendServices.NewHandler(friendStore, neighborStore)
 	friendHandler.RegisterRoutes(subrouter)
 
+	eventStore := eventControllers.NewStore(s.db)
+	eventHandler := eventServices.NewHandler(eventStore, neighborStore, zipcodeStore, addressStore, notificationStore, friendStore, imageStore, blobStore)
+	eventHandler.RegisterRoutes(subrouter)
+
+	calendarStore := calendarControllers.NewStore(s.db)
+	calendarHandler := calendarServices.NewHandler(calendarStore, eventStore, neighborStore, zipcodeStore, friendStore)
+	calendarHandler.RegisterRoutes(subrouter)
+
+	profileStore := profileControllers.NewStore(s.db)
+	profileHandler := profileServices.NewHandler(profileStore, neighborStore, friendStore, imageStore, blobStore)
+	profileHandler.RegisterRoutes(subrouter)
+
+	meHandler := meServices.NewHandler(neighborStore, addressStore, eventStore, friendStore, notificationStore, profileStore, tokenStore, mailer)
+	meHandler.RegisterRoutes(subrouter)
+	meServices.StartPurge(neighborStore, time.Hour)
+	imageServices.StartSweep(imageStore, blobStore, time.Hour)
+
 	if Port == "" {
 		Port = "8080"
 
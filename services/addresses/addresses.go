@@ -29,13 +29,23 @@ func (h *Handler) RegisterRoutes(router *mux.Router) {
 
 func (h *Handler) handleGetAddresses(w http.ResponseWriter, r *http.Request) {
 	neighborId := auth.GetNeighborIdFromContext(r.Context())
-	addresses, err := h.store.GetAddressesByNeighborId(neighborId)
+
+	var cursor types.Cursor
+	limit, err := utils.ReadPagination(r.URL.Query(), &cursor)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	addresses, err := h.store.GetAddressesByNeighborId(neighborId, types.Pagination{Limit: limit + 1, After: cursor})
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
 		return
 	}
 
-	utils.WriteJSON(w, http.StatusOK, addresses)
+	utils.WritePage(w, http.StatusOK, addresses, limit, func(address types.Addresses) any {
+		return types.Cursor{Key: address.Address, Id: address.Id}
+	})
 }
 
 func (h *Handler) handleCreateAddress(w http.ResponseWriter, r *http.Request) {
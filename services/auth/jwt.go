@@ -24,7 +24,11 @@ func NewStore(db *sql.DB) *Store {
 
 type contextKey string
 
-const NeighborKey contextKey = "neighborId"
+const (
+	NeighborKey contextKey = "neighborId"
+	SessionKey  contextKey = "jti"
+	RoleKey     contextKey = "role"
+)
 
 func WithJWTAuth(handlerFunc http.HandlerFunc, store types.NeighborStore) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -42,7 +46,13 @@ func WithJWTAuth(handlerFunc http.HandlerFunc, store types.NeighborStore) http.H
 		}
 
 		claims := token.Claims.(jwt.MapClaims)
-		str := claims["neighborId"].(string)
+		if _, ok := claims["purpose"]; ok {
+			permissionDenied(w)
+			return
+		}
+
+		str, _ := claims["neighborId"].(string)
+		jti, _ := claims["jti"].(string)
 
 		neighborId, err := strconv.Atoi(str)
 		if err != nil {
@@ -50,26 +60,60 @@ func WithJWTAuth(handlerFunc http.HandlerFunc, store types.NeighborStore) http.H
 			return
 		}
 
+		// logging out revokes the session, which takes its access tokens with it
+		session, err := store.GetSessionByJti(jti)
+		if err != nil || session.Id == 0 || session.Revoked || session.NeighborId != neighborId {
+			permissionDenied(w)
+			return
+		}
+
 		neighbor, err := store.GetNeighborById(neighborId)
 		if err != nil {
 			permissionDenied(w)
 			return
 		}
 
+		// tokens from before the neighbor's last password change don't match anymore
+		tokenVersion, _ := claims["tokenVersion"].(float64)
+		if int(tokenVersion) != neighbor.TokenVersion {
+			permissionDenied(w)
+			return
+		}
+
 		ctx := r.Context()
 		ctx = context.WithValue(ctx, NeighborKey, neighbor.Id)
+		ctx = context.WithValue(ctx, SessionKey, jti)
+		ctx = context.WithValue(ctx, RoleKey, neighbor.Role)
 		r = r.WithContext(ctx)
 
 		handlerFunc(w, r)
 	}
 }
 
-func CreateJWT(secret []byte, neighborId int) (string, error) {
+// WithOptionalJWTAuth lets requests without a token through, GetNeighborIdFromContext returns -1 for them
+func WithOptionalJWTAuth(handlerFunc http.HandlerFunc, store types.NeighborStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if getTokenFromRequest(r) == "" {
+			handlerFunc(w, r)
+			return
+		}
+
+		WithJWTAuth(handlerFunc, store)(w, r)
+	}
+}
+
+// access tokens are short lived, clients get new ones with the session's refresh token
+func CreateJWT(secret []byte, neighbor *types.Neighbors, jti string) (string, error) {
 	expiration := time.Second * time.Duration(config.Envs.JWTExpirationInSeconds)
+	now := time.Now()
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"neighborId": strconv.Itoa(neighborId),
-		"expiredAt":  time.Now().Add(expiration).Unix(),
+		"neighborId":   strconv.Itoa(neighbor.Id),
+		"tokenVersion": neighbor.TokenVersion,
+		"iat":          now.Unix(),
+		"exp":          now.Add(expiration).Unix(),
+		"jti":          jti,
+		"role":         neighbor.Role,
 	})
 
 	tokenString, err := token.SignedString(secret)
@@ -80,6 +124,47 @@ func CreateJWT(secret []byte, neighborId int) (string, error) {
 	return tokenString, nil
 }
 
+const twoFactorPurpose = "2fa"
+
+// CreateTwoFactorToken stands in for the session between the password and the code, it can't be used as an access token
+func CreateTwoFactorToken(secret []byte, neighbor *types.Neighbors) (string, error) {
+	expiration := time.Second * time.Duration(config.Envs.TwoFactorTokenExpirationInSeconds)
+	now := time.Now()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"neighborId":   strconv.Itoa(neighbor.Id),
+		"tokenVersion": neighbor.TokenVersion,
+		"purpose":      twoFactorPurpose,
+		"iat":          now.Unix(),
+		"exp":          now.Add(expiration).Unix(),
+	})
+
+	return token.SignedString(secret)
+}
+
+// ParseTwoFactorToken returns the neighbor the token was issued to along with the token version at the time
+func ParseTwoFactorToken(tokenString string) (int, int, bool) {
+	token, err := validateToken(tokenString)
+	if err != nil || !token.Valid {
+		return 0, 0, false
+	}
+
+	claims := token.Claims.(jwt.MapClaims)
+	if purpose, _ := claims["purpose"].(string); purpose != twoFactorPurpose {
+		return 0, 0, false
+	}
+
+	str, _ := claims["neighborId"].(string)
+	neighborId, err := strconv.Atoi(str)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	tokenVersion, _ := claims["tokenVersion"].(float64)
+
+	return neighborId, int(tokenVersion), true
+}
+
 func getTokenFromRequest(r *http.Request) string {
 	tokenAuth := r.Header.Get("Authorization")
 
@@ -97,13 +182,25 @@ func validateToken(t string) (*jwt.Token, error) {
 		}
 
 		return []byte(config.Envs.JWTSecret), nil
-	})
+	}, jwt.WithExpirationRequired(), jwt.WithIssuedAt())
 }
 
 func permissionDenied(w http.ResponseWriter) {
 	utils.WriteError(w, http.StatusForbidden, fmt.Errorf("permission denied"))
 }
 
+func GetSessionIdFromContext(ctx context.Context) string {
+	jti, _ := ctx.Value(SessionKey).(string)
+
+	return jti
+}
+
+func GetRoleFromContext(ctx context.Context) string {
+	role, _ := ctx.Value(RoleKey).(string)
+
+	return role
+}
+
 func GetNeighborIdFromContext(ctx context.Context) int {
 	neighborId, ok := ctx.Value(NeighborKey).(int)
 	if !ok {
@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jamesdavidyu/neighborhost-service/cmd/model/types"
+)
+
+const (
+	attemptWindow = time.Hour * 24
+	backoffBase   = time.Second
+	maxBackoff    = time.Minute * 15
+)
+
+// failures past free double the wait before the next try, reaching lockoutAfter locks the key for lockout
+type attemptPolicy struct {
+	free         int
+	lockoutAfter int
+	lockout      time.Duration
+}
+
+// AccountLockout is how long an account stays locked unless it's unlocked through email
+const AccountLockout = time.Hour * 24
+
+var (
+	accountPolicy = attemptPolicy{free: 3, lockoutAfter: 10, lockout: AccountLockout}
+	ipPolicy      = attemptPolicy{free: 20, lockoutAfter: 100, lockout: time.Hour}
+)
+
+func (p attemptPolicy) lockFor(failures int) time.Duration {
+	if failures >= p.lockoutAfter {
+		return p.lockout
+	}
+
+	if failures <= p.free {
+		return 0
+	}
+
+	return min(backoffBase<<(failures-p.free-1), maxBackoff)
+}
+
+// LoginLimiter tracks failed logins per account and per ip address
+type LoginLimiter struct {
+	store types.LoginAttemptStore
+}
+
+func NewLoginLimiter(store types.LoginAttemptStore) *LoginLimiter {
+	return &LoginLimiter{store: store}
+}
+
+func accountKey(neighborId int) string {
+	return fmt.Sprintf("neighbor:%d", neighborId)
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// RetryAfter is how long until the account or ip can try again, neighborId is 0 for unknown accounts
+func (l *LoginLimiter) RetryAfter(neighborId int, ip string) (time.Duration, error) {
+	retryAfter, err := l.store.GetLoginLock(ipKey(ip))
+	if err != nil {
+		return 0, err
+	}
+
+	if neighborId == 0 {
+		return retryAfter, nil
+	}
+
+	accountRetryAfter, err := l.store.GetLoginLock(accountKey(neighborId))
+	if err != nil {
+		return 0, err
+	}
+
+	return max(retryAfter, accountRetryAfter), nil
+}
+
+// Fail records a failed login, lockedOut is only true for the failure that locked the account
+func (l *LoginLimiter) Fail(neighborId int, ip string) (bool, error) {
+	if _, err := l.fail(ipKey(ip), ipPolicy); err != nil {
+		return false, err
+	}
+
+	if neighborId == 0 {
+		return false, nil
+	}
+
+	failures, err := l.fail(accountKey(neighborId), accountPolicy)
+	if err != nil {
+		return false, err
+	}
+
+	return failures == accountPolicy.lockoutAfter, nil
+}
+
+func (l *LoginLimiter) fail(key string, policy attemptPolicy) (int, error) {
+	failures, err := l.store.RecordFailedLogin(key, attemptWindow)
+	if err != nil {
+		return 0, err
+	}
+
+	if lockFor := policy.lockFor(failures); lockFor > 0 {
+		if err := l.store.LockLogin(key, lockFor); err != nil {
+			return 0, err
+		}
+	}
+
+	return failures, nil
+}
+
+// Unlock clears the account's failures, after a successful login or from the unlock email.
+// The ip's failures are kept so one good password doesn't reset guessing at other accounts.
+func (l *LoginLimiter) Unlock(neighborId int) error {
+	return l.store.ClearLoginAttempts(accountKey(neighborId))
+}
@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/jamesdavidyu/neighborhost-service/cmd/model/types"
+	"github.com/jamesdavidyu/neighborhost-service/utils"
+)
+
+const (
+	RoleAdmin     = "admin"
+	RoleModerator = "moderator"
+	RoleMember    = "member"
+)
+
+type Permission string
+
+const (
+	ManageRoles          Permission = "manage_roles"
+	ManageNeighborhoods  Permission = "manage_neighborhoods"
+	ModerateNeighborhood Permission = "moderate_neighborhood"
+	ManageInterests      Permission = "manage_interests"
+)
+
+// moderator permissions only apply in the neighborhoods they moderate
+var rolePermissions = map[string][]Permission{
+	RoleAdmin:     {ManageRoles, ManageNeighborhoods, ModerateNeighborhood, ManageInterests},
+	RoleModerator: {ModerateNeighborhood},
+	RoleMember:    {},
+}
+
+// NeighborhoodScope finds the neighborhood a request is about, 0 when it isn't about one
+type NeighborhoodScope func(r *http.Request) (int, error)
+
+// NeighborhoodFromVars scopes requests to the {neighborhoodId} in their path
+func NeighborhoodFromVars(r *http.Request) (int, error) {
+	return strconv.Atoi(mux.Vars(r)["neighborhoodId"])
+}
+
+// HasPermission checks the neighbor's stored role first, then whether they moderate the neighborhood
+func HasPermission(store types.NeighborStore, neighborId int, role string, permission Permission, neighborhoodId int) (bool, error) {
+	if slices.Contains(rolePermissions[role], permission) {
+		return true, nil
+	}
+
+	if neighborhoodId == 0 || !slices.Contains(rolePermissions[RoleModerator], permission) {
+		return false, nil
+	}
+
+	return store.IsModerator(neighborId, neighborhoodId)
+}
+
+// WithRole goes inside WithJWTAuth, e.g. auth.WithJWTAuth(auth.WithRole(h.handler, auth.RoleAdmin), store)
+func WithRole(handlerFunc http.HandlerFunc, roles ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !slices.Contains(roles, GetRoleFromContext(r.Context())) {
+			permissionDenied(w)
+			return
+		}
+
+		handlerFunc(w, r)
+	}
+}
+
+// RequirePermission goes inside WithJWTAuth, scope is nil for permissions that aren't tied to a neighborhood
+func RequirePermission(handlerFunc http.HandlerFunc, store types.NeighborStore, permission Permission, scope NeighborhoodScope) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		neighborhoodId := 0
+		if scope != nil {
+			id, err := scope(r)
+			if err != nil {
+				utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+				return
+			}
+			neighborhoodId = id
+		}
+
+		ok, err := HasPermission(store, GetNeighborIdFromContext(r.Context()), GetRoleFromContext(r.Context()), permission, neighborhoodId)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+			return
+		}
+
+		if !ok {
+			permissionDenied(w)
+			return
+		}
+
+		handlerFunc(w, r)
+	}
+}
@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/jamesdavidyu/neighborhost-service/cmd/model/types"
+	"github.com/jamesdavidyu/neighborhost-service/config"
+)
+
+type TokenPair struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+}
+
+// CreateSession logs the neighbor in on a new device
+func CreateSession(store types.NeighborStore, neighbor *types.Neighbors) (*TokenPair, error) {
+	jti, _, err := CreateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, refreshTokenHash, err := CreateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	err = store.CreateSession(types.Sessions{
+		NeighborId:       neighbor.Id,
+		Jti:              jti,
+		RefreshTokenHash: refreshTokenHash,
+	}, refreshExpiration())
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := CreateJWT([]byte(config.Envs.JWTSecret), neighbor, jti)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{Token: token, RefreshToken: refreshToken}, nil
+}
+
+// RefreshSession trades a refresh token for a new pair, the old refresh token and access token stop working.
+// Returns nil when the refresh token is unknown, expired, revoked or was already used.
+func RefreshSession(store types.NeighborStore, refreshToken string) (*TokenPair, error) {
+	jti, _, err := CreateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	newRefreshToken, newRefreshTokenHash, err := CreateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := store.RotateSession(HashToken(refreshToken), types.Sessions{
+		Jti:              jti,
+		RefreshTokenHash: newRefreshTokenHash,
+	}, refreshExpiration())
+	if err != nil {
+		return nil, err
+	}
+
+	if session.Id == 0 {
+		return nil, nil
+	}
+
+	neighbor, err := store.GetNeighborById(session.NeighborId)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := CreateJWT([]byte(config.Envs.JWTSecret), neighbor, jti)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{Token: token, RefreshToken: newRefreshToken}, nil
+}
+
+func refreshExpiration() time.Duration {
+	return time.Duration(config.Envs.RefreshTokenExpirationInSeconds) * time.Second
+}
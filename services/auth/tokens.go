@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// CreateOpaqueToken returns a random token for links and feeds along with the hash that gets stored instead of it
+func CreateOpaqueToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	return token, HashToken(token), nil
+}
+
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateSignedToken is an opaque token signed for one purpose, so a token from one flow can't be used in another
+// and made up tokens are turned away before touching the database
+func CreateSignedToken(secret []byte, purpose string) (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	value := base64.RawURLEncoding.EncodeToString(b)
+	token := value + "." + signToken(secret, purpose, value)
+
+	return token, HashToken(token), nil
+}
+
+func VerifySignedToken(secret []byte, purpose string, token string) bool {
+	value, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+
+	return hmac.Equal([]byte(signature), []byte(signToken(secret, purpose, value)))
+}
+
+func signToken(secret []byte, purpose string, value string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(purpose + "." + value))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
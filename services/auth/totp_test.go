@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// the RFC 6238 appendix B seed and SHA-1 vectors, the last 6 of their 8 digits
+var rfcSecret = totpEncoding.EncodeToString([]byte("12345678901234567890"))
+
+var rfcVectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestTOTPCode(t *testing.T) {
+	key := []byte("12345678901234567890")
+	for _, vector := range rfcVectors {
+		if got := totpCode(key, vector.unix/totpPeriod); got != vector.code {
+			t.Errorf("at %d got %s, want %s", vector.unix, got, vector.code)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	for _, vector := range rfcVectors {
+		step, ok := ValidateTOTP(rfcSecret, vector.code, time.Unix(vector.unix, 0))
+		if !ok || step != vector.unix/totpPeriod {
+			t.Errorf("at %d got step %d and %v, want step %d", vector.unix, step, ok, vector.unix/totpPeriod)
+		}
+	}
+}
+
+func TestValidateTOTPSkew(t *testing.T) {
+	const unix = 1111111111
+	tests := []struct {
+		name   string
+		offset time.Duration
+		want   bool
+	}{
+		{"same step", 0, true},
+		{"a step behind", -30 * time.Second, true},
+		{"a step ahead", 30 * time.Second, true},
+		{"two steps behind", -60 * time.Second, false},
+		{"two steps ahead", 60 * time.Second, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			step, ok := ValidateTOTP(rfcSecret, "050471", time.Unix(unix, 0).Add(test.offset))
+			if ok != test.want {
+				t.Errorf("got %v, want %v", ok, test.want)
+			}
+
+			if ok && step != unix/totpPeriod {
+				t.Errorf("got step %d, want the step the code was made for %d", step, unix/totpPeriod)
+			}
+		})
+	}
+}
+
+func TestValidateTOTPInput(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+		want   bool
+	}{
+		{"spaced code", rfcSecret, "050 471", true},
+		{"lower case secret", strings.ToLower(rfcSecret), "050471", true},
+		{"wrong code", rfcSecret, "050472", false},
+		{"short code", rfcSecret, "50471", false},
+		{"8 digit code", rfcSecret, "14050471", false},
+		{"bad secret", "not base32!", "050471", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, ok := ValidateTOTP(test.secret, test.code, now); ok != test.want {
+				t.Errorf("got %v, want %v", ok, test.want)
+			}
+		})
+	}
+}
+
+func TestHashRecoveryCode(t *testing.T) {
+	want := HashRecoveryCode("abcde-fghij")
+	for _, code := range []string{"abcdefghij", "ABCDE-FGHIJ", "abcde fghij", " Abc-De FGH-ij "} {
+		if got := HashRecoveryCode(code); got != want {
+			t.Errorf("%q hashed differently from abcde-fghij", code)
+		}
+	}
+
+	if HashRecoveryCode("abcde-fghik") == want {
+		t.Error("different codes hashed the same")
+	}
+}
+
+func TestCreateRecoveryCodes(t *testing.T) {
+	codes, hashes, err := CreateRecoveryCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[string]bool)
+	for i, code := range codes {
+		if len(code) != recoveryCodeLength+1 || code[recoveryCodeLength/2] != '-' {
+			t.Errorf("got %q, want %d characters split by a dash", code, recoveryCodeLength)
+		}
+
+		if hashes[i] != HashRecoveryCode(code) {
+			t.Errorf("hash %d doesn't match its code", i)
+		}
+
+		if seen[code] {
+			t.Errorf("%q came up twice", code)
+		}
+		seen[code] = true
+	}
+}
@@ -0,0 +1,211 @@
+package calendar
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jamesdavidyu/neighborhost-service/cmd/model/types"
+	"github.com/jamesdavidyu/neighborhost-service/config"
+	"github.com/jamesdavidyu/neighborhost-service/services/auth"
+	"github.com/jamesdavidyu/neighborhost-service/services/events"
+	"github.com/jamesdavidyu/neighborhost-service/utils"
+)
+
+type Handler struct {
+	store         types.CalendarStore
+	eventStore    types.EventStore
+	neighborStore types.NeighborStore
+	zipcodeStore  types.ZipcodeStore
+	friendStore   types.FriendStore
+}
+
+func NewHandler(store types.CalendarStore, eventStore types.EventStore, neighborStore types.NeighborStore, zipcodeStore types.ZipcodeStore, friendStore types.FriendStore) *Handler {
+	return &Handler{store: store, eventStore: eventStore, neighborStore: neighborStore, zipcodeStore: zipcodeStore, friendStore: friendStore}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/events/{eventId:[0-9]+}.ics", auth.WithOptionalJWTAuth(h.handleGetEventCalendar, h.neighborStore)).Methods("GET")
+	router.HandleFunc("/calendar/feed/auth", auth.WithJWTAuth(h.handleCreateCalendarFeed, h.neighborStore)).Methods("POST")
+	router.HandleFunc("/calendar/feed/auth", auth.WithJWTAuth(h.handleDeleteCalendarFeed, h.neighborStore)).Methods("DELETE")
+	router.HandleFunc("/calendar/{token}.ics", h.handleGetCalendarFeed).Methods("GET")
+}
+
+func (h *Handler) handleGetEventCalendar(w http.ResponseWriter, r *http.Request) {
+	neighborId := auth.GetNeighborIdFromContext(r.Context())
+
+	eventId, err := strconv.Atoi(mux.Vars(r)["eventId"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return
+	}
+
+	event, err := h.eventStore.GetEventWithAddressById(eventId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	if event.Id == 0 {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("not found"))
+		return
+	}
+
+	viewer, err := events.LoadViewer(neighborId, h.neighborStore)
+	if err == nil {
+		err = viewer.Relate([]int{event.HostId}, []int{event.Id}, h.friendStore, h.eventStore)
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	visible, ok := viewer.See(*event)
+	if !ok {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("not found"))
+		return
+	}
+
+	locations := make(map[string]*time.Location)
+	calendarEvents := []calendarEvent{{
+		event: visible,
+		loc:   h.getLocation(locations, visible.Zipcode),
+	}}
+
+	writeCalendar(w, fmt.Sprintf("event-%d.ics", visible.Id), renderCalendar(visible.Name, calendarEvents, time.Now()))
+}
+
+// only the hash of the feed token is stored, so the url can only be shown right after it's created
+func (h *Handler) handleCreateCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	neighborId := auth.GetNeighborIdFromContext(r.Context())
+
+	token, tokenHash, err := auth.CreateOpaqueToken()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = h.store.UpsertCalendarFeed(types.CalendarFeeds{
+		NeighborId: neighborId,
+		TokenHash:  tokenHash,
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, map[string]string{
+		"url": config.Envs.PublicURL + "/api/v1/calendar/" + token + ".ics",
+	})
+}
+
+func (h *Handler) handleDeleteCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	neighborId := auth.GetNeighborIdFromContext(r.Context())
+
+	if err := h.store.DeleteCalendarFeed(neighborId); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// the token stands in for the neighbor's login since calendar apps can't send one
+func (h *Handler) handleGetCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	feed, err := h.store.GetCalendarFeedByTokenHash(auth.HashToken(mux.Vars(r)["token"]))
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	if feed.Id == 0 {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("not found"))
+		return
+	}
+
+	neighbor, err := h.neighborStore.GetNeighborById(feed.NeighborId)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("not found"))
+		return
+	}
+
+	locations := make(map[string]*time.Location)
+	location := h.getLocation(locations, neighbor.Zipcode)
+	if location == nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("not found"))
+		return
+	}
+
+	// keeps recently finished events around so they don't vanish from calendars the moment they end
+	since := time.Now().In(location).AddDate(0, 0, -30)
+
+	// calendar apps expand repeating events themselves, so series are returned once with their rule
+	viewer, err := events.LoadViewer(neighbor.Id, h.neighborStore)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	query := types.EventQuery{
+		From:          since,
+		FromInclusive: true,
+		Series:        true,
+	}
+	viewer.Scope(&query)
+	if utils.ReadString(r.URL.Query(), "location", "my_zipcode") == "my_neighborhood" {
+		query.NeighborhoodId = neighbor.NeighborhoodId
+	} else {
+		query.Zipcode = neighbor.Zipcode
+	}
+
+	feedEvents, err := h.eventStore.QueryEvents(query)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	if err := viewer.RelateEvents(feedEvents, h.friendStore, h.eventStore); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	feedEvents = viewer.Filter(feedEvents)
+	calendarEvents := make([]calendarEvent, 0, len(feedEvents))
+	for _, event := range feedEvents {
+		calendarEvents = append(calendarEvents, calendarEvent{
+			event: event,
+			loc:   h.getLocation(locations, event.Zipcode),
+		})
+	}
+
+	writeCalendar(w, "neighborhost.ics", renderCalendar("Neighborhost", calendarEvents, time.Now()))
+}
+
+// timezones are looked up once per zipcode, nil means the zipcode has no usable timezone
+func (h *Handler) getLocation(locations map[string]*time.Location, zipcode string) *time.Location {
+	if location, ok := locations[zipcode]; ok {
+		return location
+	}
+
+	var location *time.Location
+	zipcodeData, err := h.zipcodeStore.GetZipcodeData(zipcode)
+	if err == nil && zipcodeData.Timezone != "" {
+		location, err = time.LoadLocation(zipcodeData.Timezone)
+		if err != nil {
+			location = nil
+		}
+	}
+
+	locations[zipcode] = location
+
+	return location
+}
+
+func writeCalendar(w http.ResponseWriter, filename string, body string) {
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="`+filename+`"`)
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(body))
+}
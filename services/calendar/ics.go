@@ -0,0 +1,262 @@
+package calendar
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jamesdavidyu/neighborhost-service/cmd/model/types"
+	"github.com/jamesdavidyu/neighborhost-service/services/recurrence"
+)
+
+const (
+	icsDateTime    = "20060102T150405"
+	icsDateTimeUTC = "20060102T150405Z"
+	maxLineOctets  = 75
+)
+
+// calendarEvent is an event with the timezone of its address, loc is nil when the zipcode has no usable timezone
+type calendarEvent struct {
+	event types.EventAddresses
+	loc   *time.Location
+}
+
+type icsWriter struct {
+	b strings.Builder
+}
+
+// lines longer than 75 octets are folded onto continuation lines starting with a space
+func (c *icsWriter) line(name string, value string) {
+	content := name + ":" + value
+	limit := maxLineOctets
+	for len(content) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+		c.b.WriteString(content[:cut] + "\r\n ")
+		content = content[cut:]
+		limit = maxLineOctets - 1
+	}
+	c.b.WriteString(content + "\r\n")
+}
+
+func escapeText(value string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(value)
+}
+
+func renderCalendar(name string, events []calendarEvent, now time.Time) string {
+	c := new(icsWriter)
+	c.line("BEGIN", "VCALENDAR")
+	c.line("VERSION", "2.0")
+	c.line("PRODID", "-//Neighborhost//Neighborhost Events//EN")
+	c.line("CALSCALE", "GREGORIAN")
+	c.line("METHOD", "PUBLISH")
+	c.line("X-WR-CALNAME", escapeText(name))
+
+	// each timezone is described once, starting from the year of its earliest event
+	years := make(map[string]int)
+	locations := make(map[string]*time.Location)
+	for _, e := range events {
+		if e.loc == nil {
+			continue
+		}
+
+		tzid := e.loc.String()
+		if year, ok := years[tzid]; !ok || e.event.Start.Year() < year {
+			years[tzid] = e.event.Start.Year()
+		}
+		locations[tzid] = e.loc
+	}
+
+	tzids := make([]string, 0, len(locations))
+	for tzid := range locations {
+		tzids = append(tzids, tzid)
+	}
+	sort.Strings(tzids)
+
+	for _, tzid := range tzids {
+		writeTimezone(c, locations[tzid], years[tzid])
+	}
+
+	for _, e := range events {
+		writeEvent(c, e, now)
+	}
+
+	c.line("END", "VCALENDAR")
+
+	return c.b.String()
+}
+
+// event times are stored as wall clock times, so they are written as is against the event's TZID
+func writeEvent(c *icsWriter, e calendarEvent, now time.Time) {
+	event := e.event
+
+	dateTime := func(name string, t time.Time) {
+		if e.loc == nil {
+			c.line(name, t.Format(icsDateTime))
+			return
+		}
+		c.line(name+";TZID="+e.loc.String(), t.Format(icsDateTime))
+	}
+
+	c.line("BEGIN", "VEVENT")
+	c.line("UID", fmt.Sprintf("event-%d@neighborhost", event.Id))
+	c.line("DTSTAMP", now.UTC().Format(icsDateTimeUTC))
+	dateTime("DTSTART", event.Start)
+	dateTime("DTEND", event.End)
+
+	rule, err := recurrence.Parse(event.Reoccurrence)
+	if err == nil && rule != nil {
+		loc := e.loc
+		if loc == nil {
+			loc = time.UTC
+		}
+		c.line("RRULE", rule.RRULE(loc))
+
+		exceptions := rule.ExceptionStarts(event.Start)
+		if len(exceptions) > 0 {
+			values := make([]string, len(exceptions))
+			for i, exception := range exceptions {
+				values[i] = exception.Format(icsDateTime)
+			}
+
+			if e.loc == nil {
+				c.line("EXDATE", strings.Join(values, ","))
+			} else {
+				c.line("EXDATE;TZID="+e.loc.String(), strings.Join(values, ","))
+			}
+		}
+	}
+
+	c.line("SUMMARY", escapeText(event.Name))
+	c.line("DESCRIPTION", escapeText(event.Description))
+
+	// the street address is blank when the viewer isn't allowed to see it
+	location := fmt.Sprintf("%s, %s %s", event.City, event.State, event.Zipcode)
+	if event.Address != "" {
+		location = event.Address + ", " + location
+	}
+	c.line("LOCATION", escapeText(location))
+
+	if event.Status == "cancelled" {
+		c.line("STATUS", "CANCELLED")
+	} else {
+		c.line("STATUS", "CONFIRMED")
+	}
+	c.line("END", "VEVENT")
+}
+
+type transition struct {
+	at         time.Time
+	fromOffset int
+	toOffset   int
+	name       string
+	dst        bool
+}
+
+func writeTimezone(c *icsWriter, loc *time.Location, year int) {
+	c.line("BEGIN", "VTIMEZONE")
+	c.line("TZID", loc.String())
+
+	transitions := findTransitions(loc, year)
+	if len(transitions) == 0 {
+		name, offset := time.Date(year, time.January, 1, 0, 0, 0, 0, loc).Zone()
+		c.line("BEGIN", "STANDARD")
+		c.line("DTSTART", "19700101T000000")
+		c.line("TZOFFSETFROM", formatOffset(offset))
+		c.line("TZOFFSETTO", formatOffset(offset))
+		c.line("TZNAME", name)
+		c.line("END", "STANDARD")
+	}
+
+	for _, t := range transitions {
+		component := "STANDARD"
+		if t.dst {
+			component = "DAYLIGHT"
+		}
+
+		// onsets are written in the local time in effect before the change
+		onset := t.at.Add(time.Duration(t.fromOffset) * time.Second).UTC()
+
+		c.line("BEGIN", component)
+		c.line("DTSTART", onset.Format(icsDateTime))
+		c.line("TZOFFSETFROM", formatOffset(t.fromOffset))
+		c.line("TZOFFSETTO", formatOffset(t.toOffset))
+		c.line("TZNAME", t.name)
+		c.line("RRULE", yearlyRule(onset))
+		c.line("END", component)
+	}
+
+	c.line("END", "VTIMEZONE")
+}
+
+// finds offset changes a day at a time, then narrows each one down to the second
+func findTransitions(loc *time.Location, year int) []transition {
+	transitions := make([]transition, 0, 2)
+
+	previous := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	_, previousOffset := previous.In(loc).Zone()
+	for previous.Year() == year {
+		next := previous.AddDate(0, 0, 1)
+		_, offset := next.In(loc).Zone()
+
+		if offset != previousOffset {
+			lo, hi := previous, next
+			for hi.Sub(lo) > time.Second {
+				mid := lo.Add(hi.Sub(lo) / 2)
+				if _, midOffset := mid.In(loc).Zone(); midOffset == previousOffset {
+					lo = mid
+				} else {
+					hi = mid
+				}
+			}
+
+			at := hi.Truncate(time.Second)
+			name, _ := at.In(loc).Zone()
+			transitions = append(transitions, transition{
+				at:         at,
+				fromOffset: previousOffset,
+				toOffset:   offset,
+				name:       name,
+				dst:        at.In(loc).IsDST(),
+			})
+			previousOffset = offset
+		}
+
+		previous = next
+	}
+
+	return transitions
+}
+
+// describes the transition as the nth (or last) weekday of its month
+func yearlyRule(onset time.Time) string {
+	weekdays := []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+	nth := (onset.Day()-1)/7 + 1
+	daysInMonth := time.Date(onset.Year(), onset.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	if nth >= 4 && onset.Day()+7 > daysInMonth {
+		nth = -1
+	}
+
+	return fmt.Sprintf("FREQ=YEARLY;BYMONTH=%d;BYDAY=%d%s", int(onset.Month()), nth, weekdays[onset.Weekday()])
+}
+
+func formatOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign = "-"
+		seconds = -seconds
+	}
+
+	return fmt.Sprintf("%s%02d%02d", sign, seconds/3600, seconds%3600/60)
+}
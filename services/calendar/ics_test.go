@@ -0,0 +1,252 @@
+package calendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jamesdavidyu/neighborhost-service/cmd/model/types"
+)
+
+var stamp = time.Date(2024, time.May, 1, 12, 0, 0, 0, time.UTC)
+
+func crlf(lines ...string) string {
+	return strings.Join(lines, "\r\n") + "\r\n"
+}
+
+func loadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skip(err)
+	}
+
+	return loc
+}
+
+func TestRenderSingleEvent(t *testing.T) {
+	event := calendarEvent{event: types.EventAddresses{
+		Id:          7,
+		Name:        "Potluck; bring a dish, or two",
+		Description: "Back yard at 12\\B\nBring crème brûlée, café au lait, crêpes or anything else — the more the merrier; kids welcome. Ünïcödé names on the sign-up sheet are fine",
+		Start:       time.Date(2024, time.June, 1, 17, 0, 0, 0, time.UTC),
+		End:         time.Date(2024, time.June, 1, 20, 0, 0, 0, time.UTC),
+		Address:     "12 Elm St",
+		City:        "Springfield",
+		State:       "IL",
+		Zipcode:     "62701",
+		Status:      "scheduled",
+	}}
+
+	// the description folds twice, both times backing up to the start of a two octet rune
+	want := crlf(
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Neighborhost//Neighborhost Events//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:Ana's events\\, mostly",
+		"BEGIN:VEVENT",
+		"UID:event-7@neighborhost",
+		"DTSTAMP:20240501T120000Z",
+		"DTSTART:20240601T170000",
+		"DTEND:20240601T200000",
+		"SUMMARY:Potluck\\; bring a dish\\, or two",
+		"DESCRIPTION:Back yard at 12\\\\B\\nBring crème brûlée\\, café au lait\\, cr",
+		" êpes or anything else — the more the merrier\\; kids welcome. Ünïcöd",
+		" é names on the sign-up sheet are fine",
+		"LOCATION:12 Elm St\\, Springfield\\, IL 62701",
+		"STATUS:CONFIRMED",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	)
+
+	if got := renderCalendar("Ana's events, mostly", []calendarEvent{event}, stamp); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRenderRecurringEvent(t *testing.T) {
+	event := calendarEvent{loc: loadLocation(t, "America/New_York"), event: types.EventAddresses{
+		Id:           9,
+		Name:         "Book club",
+		Description:  "Second floor",
+		Start:        time.Date(2024, time.January, 4, 19, 30, 0, 0, time.UTC),
+		End:          time.Date(2024, time.January, 4, 21, 0, 0, 0, time.UTC),
+		Reoccurrence: "FREQ=WEEKLY;UNTIL=20241231;EXDATE=20240704,20241128",
+		City:         "Albany",
+		State:        "NY",
+		Zipcode:      "12207",
+		Status:       "cancelled",
+	}}
+
+	// the until is the end of new year's eve in EST, exdates keep the event's time of day
+	want := crlf(
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Neighborhost//Neighborhost Events//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:Book club",
+		"BEGIN:VTIMEZONE",
+		"TZID:America/New_York",
+		"BEGIN:DAYLIGHT",
+		"DTSTART:20240310T020000",
+		"TZOFFSETFROM:-0500",
+		"TZOFFSETTO:-0400",
+		"TZNAME:EDT",
+		"RRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=2SU",
+		"END:DAYLIGHT",
+		"BEGIN:STANDARD",
+		"DTSTART:20241103T020000",
+		"TZOFFSETFROM:-0400",
+		"TZOFFSETTO:-0500",
+		"TZNAME:EST",
+		"RRULE:FREQ=YEARLY;BYMONTH=11;BYDAY=1SU",
+		"END:STANDARD",
+		"END:VTIMEZONE",
+		"BEGIN:VEVENT",
+		"UID:event-9@neighborhost",
+		"DTSTAMP:20240501T120000Z",
+		"DTSTART;TZID=America/New_York:20240104T193000",
+		"DTEND;TZID=America/New_York:20240104T210000",
+		"RRULE:FREQ=WEEKLY;UNTIL=20250101T045959Z",
+		"EXDATE;TZID=America/New_York:20240704T193000,20241128T193000",
+		"SUMMARY:Book club",
+		"DESCRIPTION:Second floor",
+		"LOCATION:Albany\\, NY 12207",
+		"STATUS:CANCELLED",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	)
+
+	if got := renderCalendar("Book club", []calendarEvent{event}, stamp); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestLineFolding(t *testing.T) {
+	values := []string{
+		"",
+		strings.Repeat("a", 74-len("X:")),
+		strings.Repeat("a", 75-len("X:")),
+		strings.Repeat("a", 76-len("X:")),
+		strings.Repeat("é", 100),
+		strings.Repeat("€", 100),
+		strings.Repeat("a€", 100),
+		strings.Repeat("🏡", 100),
+	}
+
+	for _, value := range values {
+		c := new(icsWriter)
+		c.line("X", value)
+
+		lines := strings.Split(strings.TrimSuffix(c.b.String(), "\r\n"), "\r\n")
+		for i, line := range lines {
+			if len(line) > maxLineOctets {
+				t.Errorf("line %d of %q is %d octets", i, value, len(line))
+			}
+
+			if !utf8.ValidString(line) {
+				t.Errorf("line %d of %q splits a rune", i, value)
+			}
+
+			if i > 0 && !strings.HasPrefix(line, " ") {
+				t.Errorf("line %d of %q doesn't start with a space", i, value)
+			}
+		}
+
+		if unfolded := strings.ReplaceAll(c.b.String(), "\r\n ", ""); unfolded != "X:"+value+"\r\n" {
+			t.Errorf("got %q back, want %q", unfolded, "X:"+value)
+		}
+	}
+}
+
+func TestEscapeText(t *testing.T) {
+	tests := map[string]string{
+		"plain":            "plain",
+		`back\slash`:       `back\\slash`,
+		"a;b,c":            `a\;b\,c`,
+		"two\nlines":       `two\nlines`,
+		"windows\r\nlines": `windows\nlines`,
+		`\n`:               `\\n`,
+	}
+
+	for value, want := range tests {
+		if got := escapeText(value); got != want {
+			t.Errorf("escapeText(%q) got %q, want %q", value, got, want)
+		}
+	}
+}
+
+func TestFindTransitions(t *testing.T) {
+	tests := []struct {
+		zone string
+		want []string
+	}{
+		{"Asia/Tokyo", []string{}},
+		{"America/Phoenix", []string{}},
+		{"Europe/London", []string{"20240331T010000 +0000 +0100 BST true", "20241027T020000 +0100 +0000 GMT false"}},
+		{"Australia/Sydney", []string{"20240407T030000 +1100 +1000 AEST false", "20241006T020000 +1000 +1100 AEDT true"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.zone, func(t *testing.T) {
+			loc := loadLocation(t, test.zone)
+
+			got := make([]string, 0)
+			for _, transition := range findTransitions(loc, 2024) {
+				onset := transition.at.Add(time.Duration(transition.fromOffset) * time.Second).UTC()
+				got = append(got, strings.Join([]string{
+					onset.Format(icsDateTime),
+					formatOffset(transition.fromOffset),
+					formatOffset(transition.toOffset),
+					transition.name,
+					map[bool]string{true: "true", false: "false"}[transition.dst],
+				}, " "))
+			}
+
+			if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestYearlyRule(t *testing.T) {
+	tests := map[string]string{
+		"20240310T020000": "FREQ=YEARLY;BYMONTH=3;BYDAY=2SU",
+		"20241103T020000": "FREQ=YEARLY;BYMONTH=11;BYDAY=1SU",
+		"20240331T010000": "FREQ=YEARLY;BYMONTH=3;BYDAY=-1SU",
+		"20241027T020000": "FREQ=YEARLY;BYMONTH=10;BYDAY=-1SU",
+		"20240324T020000": "FREQ=YEARLY;BYMONTH=3;BYDAY=4SU",
+	}
+
+	for onset, want := range tests {
+		at, err := time.Parse(icsDateTime, onset)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := yearlyRule(at); got != want {
+			t.Errorf("%s got %s, want %s", onset, got, want)
+		}
+	}
+}
+
+func TestFormatOffset(t *testing.T) {
+	tests := map[int]string{
+		0:      "+0000",
+		-18000: "-0500",
+		19800:  "+0530",
+		-12600: "-0330",
+	}
+
+	for seconds, want := range tests {
+		if got := formatOffset(seconds); got != want {
+			t.Errorf("%d got %s, want %s", seconds, got, want)
+		}
+	}
+}
@@ -3,46 +3,77 @@ package events
 import (
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
 	"github.com/jamesdavidyu/neighborhost-service/cmd/model/types"
 	"github.com/jamesdavidyu/neighborhost-service/services/auth"
+	"github.com/jamesdavidyu/neighborhost-service/services/images"
+	"github.com/jamesdavidyu/neighborhost-service/services/recurrence"
 	"github.com/jamesdavidyu/neighborhost-service/utils"
 )
 
 type Handler struct {
-	store         types.EventStore
-	neighborStore types.NeighborStore
-	zipcodeStore  types.ZipcodeStore
-	addressStore  types.AddressStore
+	store             types.EventStore
+	neighborStore     types.NeighborStore
+	zipcodeStore      types.ZipcodeStore
+	addressStore      types.AddressStore
+	notificationStore types.NotificationStore
+	friendStore       types.FriendStore
+	imageStore        types.ImageStore
+	blobStore         types.BlobStore
 }
 
-func NewHandler(store types.EventStore, neighborStore types.NeighborStore, zipcodeStore types.ZipcodeStore, addressStore types.AddressStore) *Handler {
-	return &Handler{store: store, neighborStore: neighborStore, zipcodeStore: zipcodeStore, addressStore: addressStore}
+func NewHandler(store types.EventStore, neighborStore types.NeighborStore, zipcodeStore types.ZipcodeStore, addressStore types.AddressStore, notificationStore types.NotificationStore, friendStore types.FriendStore, imageStore types.ImageStore, blobStore types.BlobStore) *Handler {
+	return &Handler{store: store, neighborStore: neighborStore, zipcodeStore: zipcodeStore, addressStore: addressStore, notificationStore: notificationStore, friendStore: friendStore, imageStore: imageStore, blobStore: blobStore}
 }
 
 func (h *Handler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/events", h.handleGetPublicEvents).Methods("GET")
 	router.HandleFunc("/events/auth", auth.WithJWTAuth(h.handleGetEvents, h.neighborStore)).Methods("GET")
 	router.HandleFunc("/events/create-event/auth", auth.WithJWTAuth(h.handleCreateEvent, h.neighborStore)).Methods("POST")
+	router.HandleFunc("/events/{eventId}/auth", auth.WithJWTAuth(h.handleUpdateEvent, h.neighborStore)).Methods("PUT")
+	router.HandleFunc("/events/{eventId}/auth", auth.WithJWTAuth(h.handleCancelEvent, h.neighborStore)).Methods("PATCH")
+	router.HandleFunc("/events/{eventId}/auth", auth.WithJWTAuth(h.handleDeleteEvent, h.neighborStore)).Methods("DELETE")
+	router.HandleFunc("/events/{eventId:[0-9]+}/cover", auth.WithOptionalJWTAuth(h.handleGetCover, h.neighborStore)).Methods("GET")
+	router.HandleFunc("/events/{eventId}/cover/auth", auth.WithJWTAuth(h.handlePutCover, h.neighborStore)).Methods("PUT")
+	router.HandleFunc("/events/{eventId}/cover/auth", auth.WithJWTAuth(h.handleDeleteCover, h.neighborStore)).Methods("DELETE")
+	router.HandleFunc("/events/{eventId}/rsvp/auth", auth.WithJWTAuth(h.handleRsvp, h.neighborStore)).Methods("POST", "PUT")
+	router.HandleFunc("/events/{eventId}/rsvp/auth", auth.WithJWTAuth(h.handleCancelRsvp, h.neighborStore)).Methods("DELETE")
+	router.HandleFunc("/events/{eventId}/attendees/auth", auth.WithJWTAuth(h.handleGetAttendees, h.neighborStore)).Methods("GET")
+	router.HandleFunc("/events/{eventId}/invites/auth", auth.WithJWTAuth(h.handleCreateEventInvites, h.neighborStore)).Methods("POST")
+	router.HandleFunc("/event-invites/auth", auth.WithJWTAuth(h.handleGetEventInvites, h.neighborStore)).Methods("GET")
+	router.HandleFunc("/event-invites/{eventId}/{status}/auth", auth.WithJWTAuth(h.handlePutEventInvite, h.neighborStore)).Methods("PUT")
+	router.HandleFunc("/moderation/events/{eventId}/auth", auth.WithJWTAuth(auth.RequirePermission(h.handleModerateCancelEvent, h.neighborStore, auth.ModerateNeighborhood, h.eventNeighborhood), h.neighborStore)).Methods("PATCH")
+	router.HandleFunc("/moderation/events/{eventId}/auth", auth.WithJWTAuth(auth.RequirePermission(h.handleModerateDeleteEvent, h.neighborStore, auth.ModerateNeighborhood, h.eventNeighborhood), h.neighborStore)).Methods("DELETE")
 }
 
 func (h *Handler) handleGetPublicEvents(w http.ResponseWriter, r *http.Request) {
-	events, err := h.store.GetPublicEvents()
+	var cursor *types.EventCursor
+	limit, err := utils.ReadPagination(r.URL.Query(), &cursor)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	events, err := h.store.GetPublicEvents(limit+1, cursor)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
 		return
 	}
 
-	utils.WriteJSON(w, http.StatusOK, events)
+	utils.WritePage(w, http.StatusOK, events, limit, func(event types.Events) any {
+		return types.EventCursor{Start: event.Start, Id: event.Id}
+	})
 }
 
 func (h *Handler) handleGetEvents(w http.ResponseWriter, r *http.Request) {
 	neighborId := auth.GetNeighborIdFromContext(r.Context())
-	var eventFilters types.EventFilterPayload
 
 	getNeighbor, err := h.neighborStore.GetNeighborById(neighborId)
 	if err != nil {
@@ -63,184 +94,111 @@ func (h *Handler) handleGetEvents(w http.ResponseWriter, r *http.Request) {
 	}
 
 	qs := r.URL.Query()
-	eventFilters.LocationFilter = utils.ReadString(qs, "location", "My zipcode")
-	eventFilters.DateFilter = utils.ReadString(qs, "starts", "") // default is on after depending on how client makes request
-	eventFilters.DateTime = utils.ReadDateTime(qs, "datetime", time.Now().In(location))
-
-	if eventFilters.LocationFilter == "my_zipcode" {
-		if eventFilters.DateFilter == "on" {
-			events, err := h.store.GetZipcodeEventsOnDate(getNeighbor.Zipcode, eventFilters.DateTime.In(location))
-			if err != nil {
-				utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
-				return
-			}
-
-			utils.WriteJSON(w, http.StatusOK, events)
-
-		} else if eventFilters.DateFilter == "before" {
-			events, err := h.store.GetZipcodeEventsBeforeDate(getNeighbor.Zipcode, eventFilters.DateTime.In(location))
-			if err != nil {
-				utils.WriteError(w, http.StatusInternalServerError, err)
-				return
-			}
-
-			utils.WriteJSON(w, http.StatusOK, events)
-
-		} else if eventFilters.DateFilter == "after" {
-			events, err := h.store.GetZipcodeEventsAfterDate(getNeighbor.Zipcode, eventFilters.DateTime.In(location))
-			if err != nil {
-				utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
-				return
-			}
-
-			utils.WriteJSON(w, http.StatusOK, events)
-
-			// TODO: two more controllers for on/after and on/before
-
-		} else {
-			events, err := h.store.GetEventsByZipcode(getNeighbor.Zipcode, time.Now().In(location))
-			if err != nil {
-				utils.WriteError(w, http.StatusInternalServerError, err)
-				return
-			}
-
-			utils.WriteJSON(w, http.StatusOK, events)
-
-		}
-
-	} else if eventFilters.LocationFilter == "my_neighborhood" {
-		if eventFilters.DateFilter == "on" {
-			events, err := h.store.GetNeighborhoodEventsOnDate(getNeighbor.NeighborhoodId, eventFilters.DateTime.In(location))
-			if err != nil {
-				utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
-				return
-			}
-
-			utils.WriteJSON(w, http.StatusOK, events)
-
-		} else if eventFilters.DateFilter == "before" {
-			events, err := h.store.GetNeighborhoodEventsBeforeDate(getNeighbor.NeighborhoodId, eventFilters.DateTime.In(location))
-			if err != nil {
-				utils.WriteError(w, http.StatusInternalServerError, err)
-				return
-			}
-
-			utils.WriteJSON(w, http.StatusOK, events)
-
-		} else if eventFilters.DateFilter == "after" {
-			events, err := h.store.GetNeighborhoodEventsAfterDate(getNeighbor.NeighborhoodId, eventFilters.DateTime.In(location))
-			if err != nil {
-				utils.WriteError(w, http.StatusInternalServerError, err)
-				return
-			}
-
-			utils.WriteJSON(w, http.StatusOK, events)
-
-		} else {
-			events, err := h.store.GetEventsByNeighborhoodId(getNeighbor.NeighborhoodId, time.Now().In(location))
-			if err != nil {
-				utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
-				return
-			}
-
-			utils.WriteJSON(w, http.StatusOK, events)
+	query := types.EventQuery{
+		HostId:   utils.ReadInt(qs, "host", 0),
+		Category: strings.ToLower(utils.ReadString(qs, "category", "")),
+		Text:     strings.TrimSpace(utils.ReadString(qs, "q", "")),
+	}
 
-		}
+	viewer, err := LoadViewer(neighborId, h.neighborStore)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+	viewer.Scope(&query)
 
-	} else if eventFilters.LocationFilter == "my_city" {
+	limit, err := utils.ReadPagination(qs, &query.Cursor)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	query.Limit = limit + 1
+
+	switch locationFilter := utils.ReadString(qs, "location", "my_zipcode"); locationFilter {
+	case "my_zipcode":
+		query.Zipcode = getNeighbor.Zipcode
+	case "my_neighborhood":
+		query.NeighborhoodId = getNeighbor.NeighborhoodId
+	case "my_city":
 		getAddress, err := h.addressStore.GetAddressByNeighborId(neighborId) // temp, need to redo zipcode table to include state abbr or just figure out making filterable zipcode service and need to know how requests come through
 		if err != nil {
 			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
 			return
 		}
 
-		if eventFilters.DateFilter == "on" {
-			events, err := h.store.GetCityEventsOnDate(getAddress.City, getAddress.State, getAddress.Zipcode, eventFilters.DateTime.In(location))
-			if err != nil {
-				utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
-				return
-			}
-
-			utils.WriteJSON(w, http.StatusOK, events)
-
-		} else if eventFilters.DateFilter == "before" {
-			events, err := h.store.GetCityEventsBeforeDate(getAddress.City, getAddress.State, getAddress.Zipcode, eventFilters.DateTime.In(location))
-			if err != nil {
-				utils.WriteError(w, http.StatusInternalServerError, err)
-				return
-			}
-
-			utils.WriteJSON(w, http.StatusOK, events)
-
-		} else if eventFilters.DateFilter == "after" {
-			events, err := h.store.GetCityEventsAfterDate(getAddress.City, getAddress.State, getAddress.Zipcode, eventFilters.DateTime.In(location))
-			if err != nil {
-				utils.WriteError(w, http.StatusInternalServerError, err)
-				return
-			}
-
-			utils.WriteJSON(w, http.StatusOK, events)
-
-		} else {
-			events, err := h.store.GetEventsByCity(getAddress.City, getAddress.State, time.Now().In(location))
-			if err != nil {
-				utils.WriteError(w, http.StatusInternalServerError, err)
-				return
-			}
-
-			utils.WriteJSON(w, http.StatusOK, events)
-
-		}
-
-	} else if eventFilters.LocationFilter != "my_neighborhood" || eventFilters.LocationFilter != "my_city" {
-		getLocation, err := h.zipcodeStore.GetZipcodeWithCityStateZipcode(eventFilters.LocationFilter)
+		query.City = getAddress.City
+		query.State = getAddress.State
+	default:
+		getLocation, err := h.zipcodeStore.GetZipcodeWithCityStateZipcode(locationFilter)
 		if err != nil {
 			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
 			return
 		}
 
-		if eventFilters.DateFilter == "on" {
-			events, err := h.store.GetCityEventsOnDate(getLocation.City, getLocation.State, getLocation.Zipcode, eventFilters.DateTime.In(location))
-			if err != nil {
-				utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
-				return
-			}
-
-			utils.WriteJSON(w, http.StatusOK, events)
-
-		} else if eventFilters.DateFilter == "before" {
-			events, err := h.store.GetCityEventsBeforeDate(getLocation.City, getLocation.State, getLocation.Zipcode, eventFilters.DateTime.In(location))
-			if err != nil {
-				utils.WriteError(w, http.StatusInternalServerError, err)
-				return
-			}
-
-			utils.WriteJSON(w, http.StatusOK, events)
+		if getLocation.Zipcode == "" {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("not found"))
+			return
+		}
 
-		} else if eventFilters.DateFilter == "after" {
-			events, err := h.store.GetCityEventsAfterDate(getLocation.City, getLocation.State, getLocation.Zipcode, eventFilters.DateTime.In(location))
-			if err != nil {
-				utils.WriteError(w, http.StatusInternalServerError, err)
-				return
-			}
+		query.City = getLocation.City
+		query.State = getLocation.State
+		query.Zipcode = getLocation.Zipcode
+	}
 
-			utils.WriteJSON(w, http.StatusOK, events)
+	dateTime := utils.ReadDateTime(qs, "datetime", time.Now()).In(location)
+	day := time.Date(dateTime.Year(), dateTime.Month(), dateTime.Day(), 0, 0, 0, 0, location)
+
+	switch utils.ReadString(qs, "starts", "") {
+	case "on":
+		query.From, query.FromInclusive = day, true
+		query.To = day.AddDate(0, 0, 1)
+	case "before":
+		query.To = dateTime
+		query.Descending = true
+	case "after":
+		query.From = dateTime
+	case "on_or_before":
+		query.To = day.AddDate(0, 0, 1)
+		query.Descending = true
+	case "on_or_after":
+		query.From, query.FromInclusive = day, true
+	case "between":
+		until := utils.ReadDateTime(qs, "until", time.Time{})
+		if until.IsZero() || until.Before(dateTime) {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+			return
+		}
 
-		} else {
-			events, err := h.store.GetEventsByZipcode(getNeighbor.Zipcode, time.Now().In(location))
-			if err != nil {
-				utils.WriteError(w, http.StatusInternalServerError, err)
-				return
-			}
+		query.From, query.FromInclusive = dateTime, true
+		query.To, query.ToInclusive = until.In(location), true
+	case "":
+		query.From, query.FromInclusive = dateTime, true
+	default:
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return
+	}
 
-			utils.WriteJSON(w, http.StatusOK, events)
+	switch utils.ReadString(qs, "sort", "") {
+	case "start":
+		query.Descending = false
+	case "-start":
+		query.Descending = true
+	}
 
-		}
+	events, err := h.store.QueryEvents(query)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
 
-	} else {
-		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+	if err := viewer.RelateEvents(events, h.friendStore, h.store); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
 		return
 	}
+
+	utils.WriteFilteredPage(w, http.StatusOK, events, limit, func(event types.EventAddresses) any {
+		return types.EventCursor{Start: event.Start, Id: event.Id}
+	}, viewer.Filter)
 }
 
 func (h *Handler) handleCreateEvent(w http.ResponseWriter, r *http.Request) {
@@ -258,6 +216,17 @@ func (h *Handler) handleCreateEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	rule, err := recurrence.Parse(event.Reoccurrence)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	reoccurrence := ""
+	if rule != nil {
+		reoccurrence = rule.String()
+	}
+
 	checkAddress, err := h.addressStore.GetAddressIdByAddress(
 		event.Address,
 		event.City,
@@ -331,12 +300,14 @@ func (h *Handler) handleCreateEvent(w http.ResponseWriter, r *http.Request) {
 			Description:    utils.ToProperCase(event.Description),
 			Start:          event.Start.In(location),
 			End:            event.End.In(location),
-			Reoccurrence:   event.Reoccurrence,
+			Reoccurrence:   reoccurrence,
 			ForUnloggedins: event.ForUnloggedins,
 			ForUnverifieds: event.ForUnverifieds,
 			InviteOnly:     event.InviteOnly,
 			HostId:         neighborId,
 			AddressId:      checkAddressAgain.Id,
+			Capacity:       event.Capacity,
+			Category:       strings.ToLower(strings.TrimSpace(event.Category)),
 		})
 		if err != nil {
 			utils.WriteError(w, http.StatusInternalServerError, err)
@@ -352,12 +323,14 @@ func (h *Handler) handleCreateEvent(w http.ResponseWriter, r *http.Request) {
 			Description:    utils.ToProperCase(event.Description),
 			Start:          event.Start.In(location),
 			End:            event.End.In(location),
-			Reoccurrence:   event.Reoccurrence,
+			Reoccurrence:   reoccurrence,
 			ForUnloggedins: event.ForUnloggedins,
 			ForUnverifieds: event.ForUnverifieds,
 			InviteOnly:     event.InviteOnly,
 			HostId:         neighborId,
 			AddressId:      checkAddress.Id,
+			Capacity:       event.Capacity,
+			Category:       strings.ToLower(strings.TrimSpace(event.Category)),
 		})
 		if err != nil {
 			utils.WriteError(w, http.StatusInternalServerError, err)
@@ -368,3 +341,566 @@ func (h *Handler) handleCreateEvent(w http.ResponseWriter, r *http.Request) {
 		json.NewEncoder(w).Encode(event)
 	}
 }
+
+func (h *Handler) handleUpdateEvent(w http.ResponseWriter, r *http.Request) {
+	neighborId := auth.GetNeighborIdFromContext(r.Context())
+	var payload types.UpdateEventPayload
+
+	event, ok := h.getHostedEventFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid submission for %v", errors))
+		return
+	}
+
+	rule, err := recurrence.Parse(payload.Reoccurrence)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	reoccurrence := ""
+	if rule != nil {
+		reoccurrence = rule.String()
+	}
+
+	getNeighbor, err := h.neighborStore.GetNeighborById(neighborId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	getZipcodeData, err := h.zipcodeStore.GetZipcodeData(getNeighbor.Zipcode)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	location, err := time.LoadLocation(getZipcodeData.Timezone)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("not found"))
+		return
+	}
+
+	err = h.store.UpdateEvent(types.Events{
+		Id:             event.Id,
+		Name:           utils.ToProperCase(payload.Name),
+		Description:    utils.ToProperCase(payload.Description),
+		Start:          payload.Start.In(location),
+		End:            payload.End.In(location),
+		Reoccurrence:   reoccurrence,
+		ForUnloggedins: payload.ForUnloggedins,
+		ForUnverifieds: payload.ForUnverifieds,
+		InviteOnly:     payload.InviteOnly,
+		Capacity:       payload.Capacity,
+		Category:       strings.ToLower(strings.TrimSpace(payload.Category)),
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	h.notifyEventNeighbors(event, "event_updated", fmt.Sprintf("%s has been updated", utils.ToProperCase(payload.Name)))
+
+	updated, err := h.store.GetEventById(event.Id)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, updated)
+}
+
+func (h *Handler) handleCancelEvent(w http.ResponseWriter, r *http.Request) {
+	event, ok := h.getHostedEventFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	h.cancelEvent(w, r, event)
+}
+
+// moderators can take down any event in their neighborhood, whether or not they could see it
+func (h *Handler) handleModerateCancelEvent(w http.ResponseWriter, r *http.Request) {
+	event, ok := h.loadEventFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	h.cancelEvent(w, r, event)
+}
+
+func (h *Handler) cancelEvent(w http.ResponseWriter, r *http.Request, event *types.Events) {
+	var payload types.CancelEventPayload
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid submission for %v", errors))
+		return
+	}
+
+	if event.Status == "cancelled" {
+		utils.WriteError(w, http.StatusConflict, fmt.Errorf("event already cancelled"))
+		return
+	}
+
+	err := h.store.CancelEvent(types.Events{
+		Id:                 event.Id,
+		CancellationReason: payload.Reason,
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	message := fmt.Sprintf("%s has been cancelled", event.Name)
+	if payload.Reason != "" {
+		message += ": " + payload.Reason
+	}
+	h.notifyEventNeighbors(event, "event_cancelled", message)
+
+	event.Status = "cancelled"
+	event.CancellationReason = payload.Reason
+
+	utils.WriteJSON(w, http.StatusOK, event)
+}
+
+// neighbors are notified first since their rsvps and invites are deleted with the event
+func (h *Handler) handleDeleteEvent(w http.ResponseWriter, r *http.Request) {
+	event, ok := h.getHostedEventFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	h.deleteEvent(w, event)
+}
+
+func (h *Handler) handleModerateDeleteEvent(w http.ResponseWriter, r *http.Request) {
+	event, ok := h.loadEventFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	h.deleteEvent(w, event)
+}
+
+func (h *Handler) deleteEvent(w http.ResponseWriter, event *types.Events) {
+	h.notifyEventNeighbors(event, "event_deleted", fmt.Sprintf("%s has been deleted", event.Name))
+
+	if err := h.store.DeleteEvent(event.Id); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// a failed notification shouldn't undo the host's change, so errors are only logged
+func (h *Handler) notifyEventNeighbors(event *types.Events, notificationType string, message string) {
+	err := h.notificationStore.CreateEventNotifications(types.Notifications{
+		EventId: event.Id,
+		Type:    notificationType,
+		Message: message,
+	})
+	if err != nil {
+		log.Printf("notifying neighbors of event %d: %v", event.Id, err)
+	}
+}
+
+// anyone who can see the event can see its cover
+func (h *Handler) handleGetCover(w http.ResponseWriter, r *http.Request) {
+	event, ok := h.getEventFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	cover, err := h.imageStore.GetEventCoverByEventId(event.Id)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	if cover.Id == 0 {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("not found"))
+		return
+	}
+
+	images.Serve(w, r, h.blobStore, cover)
+}
+
+// multipart with the picture in the image field, only the host can change the cover
+func (h *Handler) handlePutCover(w http.ResponseWriter, r *http.Request) {
+	event, ok := h.getHostedEventFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	image, ok := images.Upload(w, r, h.blobStore, fmt.Sprintf("events/%d", event.Id), images.CoverSize, images.CoverThumbnailSize)
+	if !ok {
+		return
+	}
+
+	if err := h.imageStore.CreateEventCover(event.Id, *image); err != nil {
+		images.Discard(h.blobStore, image)
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	cover, err := h.imageStore.GetEventCoverByEventId(event.Id)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, cover)
+}
+
+func (h *Handler) handleDeleteCover(w http.ResponseWriter, r *http.Request) {
+	event, ok := h.getHostedEventFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	deleted, err := h.imageStore.DeleteEventCover(event.Id)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	if !deleted {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("not found"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) handleRsvp(w http.ResponseWriter, r *http.Request) {
+	neighborId := auth.GetNeighborIdFromContext(r.Context())
+	var rsvp types.RsvpPayload
+
+	event, ok := h.getEventFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&rsvp); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return
+	}
+
+	if err := utils.Validate.Struct(rsvp); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid submission for %v", errors))
+		return
+	}
+
+	if event.Status == "cancelled" {
+		utils.WriteError(w, http.StatusConflict, fmt.Errorf("event has been cancelled"))
+		return
+	}
+
+	saved, err := h.store.RespondToEvent(types.EventRsvps{
+		EventId:    event.Id,
+		NeighborId: neighborId,
+		Status:     rsvp.Status,
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, saved)
+}
+
+func (h *Handler) handleCancelRsvp(w http.ResponseWriter, r *http.Request) {
+	neighborId := auth.GetNeighborIdFromContext(r.Context())
+
+	event, ok := h.getEventFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.store.CancelRsvp(event.Id, neighborId); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// only the host can see who is coming
+func (h *Handler) handleGetAttendees(w http.ResponseWriter, r *http.Request) {
+	neighborId := auth.GetNeighborIdFromContext(r.Context())
+
+	event, ok := h.getEventFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if event.HostId != neighborId {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("permission denied"))
+		return
+	}
+
+	attendees, err := h.store.GetAttendeesByEventId(event.Id)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, attendees)
+}
+
+// neighbors who blocked the host look the same as neighbors who don't exist
+func (h *Handler) handleCreateEventInvites(w http.ResponseWriter, r *http.Request) {
+	neighborId := auth.GetNeighborIdFromContext(r.Context())
+	var payload types.EventInvitePayload
+
+	event, ok := h.getEventFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if event.HostId != neighborId {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("permission denied"))
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return
+	}
+
+	inviteeIds := make([]int, 0, len(payload.NeighborIds)+len(payload.Usernames))
+	for _, id := range payload.NeighborIds {
+		invitee, err := h.neighborStore.GetNeighborById(id)
+		if err != nil {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("neighbor %d not found", id))
+			return
+		}
+
+		blocked, err := h.friendStore.IsBlocked(invitee.Id, neighborId)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+			return
+		}
+
+		if blocked {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("neighbor %d not found", id))
+			return
+		}
+		inviteeIds = append(inviteeIds, invitee.Id)
+	}
+
+	for _, username := range payload.Usernames {
+		invitee, err := h.neighborStore.GetNeighborWithUsername(username)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+			return
+		}
+
+		if invitee.Id == 0 {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("neighbor %s not found", username))
+			return
+		}
+
+		blocked, err := h.friendStore.IsBlocked(invitee.Id, neighborId)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+			return
+		}
+
+		if blocked {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("neighbor %s not found", username))
+			return
+		}
+		inviteeIds = append(inviteeIds, invitee.Id)
+	}
+
+	invited := make([]int, 0, len(inviteeIds))
+	for _, inviteeId := range inviteeIds {
+		if inviteeId == neighborId {
+			continue
+		}
+
+		err := h.store.CreateEventInvite(types.EventInvites{
+			EventId:           event.Id,
+			InvitedNeighborId: inviteeId,
+		})
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+			return
+		}
+		invited = append(invited, inviteeId)
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, map[string]any{"eventId": event.Id, "invitedNeighborIds": invited})
+}
+
+func (h *Handler) handleGetEventInvites(w http.ResponseWriter, r *http.Request) {
+	neighborId := auth.GetNeighborIdFromContext(r.Context())
+
+	invites, err := h.store.GetEventInvitesByNeighborId(neighborId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, invites)
+}
+
+// accepting an invite also rsvps the invitee as going
+// the invite is what lets the neighbor answer, so they can change their mind after declining an invite-only event
+func (h *Handler) handlePutEventInvite(w http.ResponseWriter, r *http.Request) {
+	neighborId := auth.GetNeighborIdFromContext(r.Context())
+
+	event, ok := h.loadEventFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	status := mux.Vars(r)["status"]
+	if status != "accepted" && status != "declined" {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return
+	}
+
+	invite, err := h.store.GetEventInvite(event.Id, neighborId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	if invite.Id == 0 {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("not found"))
+		return
+	}
+
+	if event.Status == "cancelled" {
+		utils.WriteError(w, http.StatusConflict, fmt.Errorf("event has been cancelled"))
+		return
+	}
+
+	err = h.store.UpdateEventInvite(types.EventInvites{
+		EventId:           event.Id,
+		InvitedNeighborId: neighborId,
+		Status:            status,
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	rsvpStatus := "going"
+	if status == "declined" {
+		rsvpStatus = "declined"
+	}
+
+	rsvp, err := h.store.RespondToEvent(types.EventRsvps{
+		EventId:    event.Id,
+		NeighborId: neighborId,
+		Status:     rsvpStatus,
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, rsvp)
+}
+
+// like getEventFromRequest, but only lets the event's host through
+func (h *Handler) getHostedEventFromRequest(w http.ResponseWriter, r *http.Request) (*types.Events, bool) {
+	neighborId := auth.GetNeighborIdFromContext(r.Context())
+
+	event, ok := h.getEventFromRequest(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	if event.HostId != neighborId {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("permission denied"))
+		return nil, false
+	}
+
+	return event, true
+}
+
+// writes the error response itself when the event can't be loaded or is invite-only and the neighbor wasn't invited
+func (h *Handler) getEventFromRequest(w http.ResponseWriter, r *http.Request) (*types.Events, bool) {
+	neighborId := auth.GetNeighborIdFromContext(r.Context())
+
+	event, ok := h.loadEventFromRequest(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	viewer, err := LoadViewer(neighborId, h.neighborStore)
+	if err == nil {
+		err = viewer.Relate([]int{event.HostId}, []int{event.Id}, h.friendStore, h.store)
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return nil, false
+	}
+
+	if !viewer.CanSee(*event) {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("not found"))
+		return nil, false
+	}
+
+	return event, true
+}
+
+// loads the event without checking whether the neighbor can see it
+func (h *Handler) loadEventFromRequest(w http.ResponseWriter, r *http.Request) (*types.Events, bool) {
+	str, ok := mux.Vars(r)["eventId"]
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return nil, false
+	}
+
+	eventId, err := strconv.Atoi(str)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return nil, false
+	}
+
+	event, err := h.store.GetEventById(eventId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return nil, false
+	}
+
+	if event.Id == 0 {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("not found"))
+		return nil, false
+	}
+
+	return event, true
+}
+
+// scopes moderation requests to the neighborhood of the event's address
+func (h *Handler) eventNeighborhood(r *http.Request) (int, error) {
+	eventId, err := strconv.Atoi(mux.Vars(r)["eventId"])
+	if err != nil {
+		return 0, err
+	}
+
+	return h.store.GetEventNeighborhoodId(eventId)
+}
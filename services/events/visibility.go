@@ -0,0 +1,203 @@
+package events
+
+import (
+	"github.com/jamesdavidyu/neighborhost-service/cmd/model/types"
+)
+
+// ViewerKind is how a neighbor relates to an event, the strongest relation wins
+type ViewerKind int
+
+const (
+	Anonymous ViewerKind = iota
+	Unverified
+	Verified
+	Friend
+	Invitee
+	Host
+)
+
+// Viewer is who is asking for events, NeighborId is -1 for neighbors that aren't logged in, the maps only cover what Relate was asked about
+type Viewer struct {
+	NeighborId      int
+	Verified        bool
+	FriendIds       map[int]bool
+	InvitedEventIds map[int]bool
+	BlockedByIds    map[int]bool // hosts who blocked the viewer, their events are hidden whatever the policy says
+}
+
+type visibility struct {
+	canSee   func(forUnloggedins bool, forUnverifieds bool, inviteOnly bool) bool
+	address  bool // street address of the event
+	hostName bool // name on the event's address
+}
+
+// keep in sync with the visibility conditions in QueryEvents
+var policy = map[ViewerKind]visibility{
+	Anonymous: {
+		canSee: func(forUnloggedins bool, forUnverifieds bool, inviteOnly bool) bool {
+			return forUnloggedins && !inviteOnly
+		},
+	},
+	Unverified: {
+		canSee: func(forUnloggedins bool, forUnverifieds bool, inviteOnly bool) bool {
+			return (forUnloggedins || forUnverifieds) && !inviteOnly
+		},
+	},
+	Verified: {
+		canSee: func(forUnloggedins bool, forUnverifieds bool, inviteOnly bool) bool {
+			return !inviteOnly
+		},
+		address: true,
+	},
+	Friend: {
+		canSee: func(forUnloggedins bool, forUnverifieds bool, inviteOnly bool) bool {
+			return !inviteOnly
+		},
+		address:  true,
+		hostName: true,
+	},
+	Invitee: {
+		canSee: func(forUnloggedins bool, forUnverifieds bool, inviteOnly bool) bool {
+			return true
+		},
+		address:  true,
+		hostName: true,
+	},
+	Host: {
+		canSee: func(forUnloggedins bool, forUnverifieds bool, inviteOnly bool) bool {
+			return true
+		},
+		address:  true,
+		hostName: true,
+	},
+}
+
+// LoadViewer looks up whether the neighbor is verified, Relate adds how they relate to the events at hand
+func LoadViewer(neighborId int, neighborStore types.NeighborStore) (Viewer, error) {
+	viewer := Viewer{
+		NeighborId:      neighborId,
+		FriendIds:       make(map[int]bool),
+		InvitedEventIds: make(map[int]bool),
+		BlockedByIds:    make(map[int]bool),
+	}
+
+	if neighborId == -1 {
+		return viewer, nil
+	}
+
+	neighbor, err := neighborStore.GetNeighborById(neighborId)
+	if err != nil {
+		return viewer, err
+	}
+	viewer.Verified = neighbor.Verified
+
+	return viewer, nil
+}
+
+// Relate looks up which of the hosts are friends or blocked the viewer and which of the events they're invited to
+func (v *Viewer) Relate(hostIds []int, eventIds []int, friendStore types.FriendStore, eventStore types.EventStore) error {
+	if v.NeighborId == -1 || len(eventIds) == 0 {
+		return nil
+	}
+
+	friendIds, err := friendStore.GetFriendIds(v.NeighborId, hostIds)
+	if err != nil {
+		return err
+	}
+
+	for _, friendId := range friendIds {
+		v.FriendIds[friendId] = true
+	}
+
+	invitedEventIds, err := eventStore.GetInvitedEventIds(v.NeighborId, eventIds)
+	if err != nil {
+		return err
+	}
+
+	for _, eventId := range invitedEventIds {
+		v.InvitedEventIds[eventId] = true
+	}
+
+	blockerIds, err := friendStore.GetBlockerIds(v.NeighborId, hostIds)
+	if err != nil {
+		return err
+	}
+
+	for _, blockerId := range blockerIds {
+		v.BlockedByIds[blockerId] = true
+	}
+
+	return nil
+}
+
+// RelateEvents is Relate for the hosts and events in a list
+func (v *Viewer) RelateEvents(events []types.EventAddresses, friendStore types.FriendStore, eventStore types.EventStore) error {
+	hostIds, eventIds := make([]int, 0, len(events)), make([]int, 0, len(events))
+	for _, event := range events {
+		hostIds, eventIds = append(hostIds, event.HostId), append(eventIds, event.Id)
+	}
+
+	return v.Relate(hostIds, eventIds, friendStore, eventStore)
+}
+
+func (v Viewer) Kind(eventId int, hostId int) ViewerKind {
+	switch {
+	case v.NeighborId == -1:
+		return Anonymous
+	case v.NeighborId == hostId:
+		return Host
+	case v.InvitedEventIds[eventId]:
+		return Invitee
+	case v.FriendIds[hostId]:
+		return Friend
+	case v.Verified:
+		return Verified
+	default:
+		return Unverified
+	}
+}
+
+// Scope narrows a query to the events the viewer can see, so pages aren't cut short by Filter
+func (v Viewer) Scope(query *types.EventQuery) {
+	query.ViewerId = v.NeighborId
+	query.PublicOnly = v.NeighborId == -1
+	query.Unverified = !v.Verified
+}
+
+func (v Viewer) CanSee(event types.Events) bool {
+	if v.BlockedByIds[event.HostId] {
+		return false
+	}
+
+	return policy[v.Kind(event.Id, event.HostId)].canSee(event.ForUnloggedins, event.ForUnverifieds, event.InviteOnly)
+}
+
+// See returns the event as the viewer is allowed to see it, or false if they can't see it at all
+func (v Viewer) See(event types.EventAddresses) (types.EventAddresses, bool) {
+	visibility := policy[v.Kind(event.Id, event.HostId)]
+	if v.BlockedByIds[event.HostId] || !visibility.canSee(event.ForUnloggedins, event.ForUnverifieds, event.InviteOnly) {
+		return event, false
+	}
+
+	if !visibility.address {
+		event.Address = ""
+	}
+
+	if !visibility.hostName {
+		event.FirstName = ""
+		event.LastName = ""
+	}
+
+	return event, true
+}
+
+func (v Viewer) Filter(events []types.EventAddresses) []types.EventAddresses {
+	visible := make([]types.EventAddresses, 0, len(events))
+	for _, event := range events {
+		if event, ok := v.See(event); ok {
+			visible = append(visible, event)
+		}
+	}
+
+	return visible
+}
@@ -0,0 +1,186 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/jamesdavidyu/neighborhost-service/cmd/model/types"
+)
+
+const (
+	hostId    = 1
+	viewerId  = 2
+	eventId   = 10
+	otherHost = 3
+)
+
+func viewers() map[ViewerKind]Viewer {
+	viewer := func(verified bool) Viewer {
+		return Viewer{
+			NeighborId:      viewerId,
+			Verified:        verified,
+			FriendIds:       make(map[int]bool),
+			InvitedEventIds: make(map[int]bool),
+			BlockedByIds:    make(map[int]bool),
+		}
+	}
+
+	anonymous := viewer(false)
+	anonymous.NeighborId = -1
+
+	friend := viewer(true)
+	friend.FriendIds[hostId] = true
+
+	invitee := viewer(true)
+	invitee.InvitedEventIds[eventId] = true
+
+	host := viewer(true)
+	host.NeighborId = hostId
+
+	return map[ViewerKind]Viewer{
+		Anonymous:  anonymous,
+		Unverified: viewer(false),
+		Verified:   viewer(true),
+		Friend:     friend,
+		Invitee:    invitee,
+		Host:       host,
+	}
+}
+
+func TestViewerKind(t *testing.T) {
+	for kind, viewer := range viewers() {
+		if got := viewer.Kind(eventId, hostId); got != kind {
+			t.Errorf("got kind %d, want %d", got, kind)
+		}
+	}
+}
+
+func TestCanSee(t *testing.T) {
+	// which viewers see an event, in the order anonymous, unverified, verified, friend, invitee, host
+	tests := []struct {
+		forUnloggedins bool
+		forUnverifieds bool
+		inviteOnly     bool
+		want           [6]bool
+	}{
+		{false, false, false, [6]bool{false, false, true, true, true, true}},
+		{false, true, false, [6]bool{false, true, true, true, true, true}},
+		{true, false, false, [6]bool{true, true, true, true, true, true}},
+		{true, true, false, [6]bool{true, true, true, true, true, true}},
+		{false, false, true, [6]bool{false, false, false, false, true, true}},
+		{false, true, true, [6]bool{false, false, false, false, true, true}},
+		{true, false, true, [6]bool{false, false, false, false, true, true}},
+		{true, true, true, [6]bool{false, false, false, false, true, true}},
+	}
+
+	for _, test := range tests {
+		event := types.Events{
+			Id:             eventId,
+			HostId:         hostId,
+			ForUnloggedins: test.forUnloggedins,
+			ForUnverifieds: test.forUnverifieds,
+			InviteOnly:     test.inviteOnly,
+		}
+		eventAddress := types.EventAddresses{
+			Id:             eventId,
+			HostId:         hostId,
+			ForUnloggedins: test.forUnloggedins,
+			ForUnverifieds: test.forUnverifieds,
+			InviteOnly:     test.inviteOnly,
+		}
+
+		for kind, viewer := range viewers() {
+			if got := viewer.CanSee(event); got != test.want[kind] {
+				t.Errorf("CanSee for viewer %d with %+v: got %v, want %v", kind, test, got, test.want[kind])
+			}
+
+			if _, got := viewer.See(eventAddress); got != test.want[kind] {
+				t.Errorf("See for viewer %d with %+v: got %v, want %v", kind, test, got, test.want[kind])
+			}
+		}
+	}
+}
+
+func TestSeeRedacts(t *testing.T) {
+	// what's left of the address and host name for each viewer, everyone can see the event
+	tests := map[ViewerKind]struct {
+		address  bool
+		hostName bool
+	}{
+		Anonymous:  {false, false},
+		Unverified: {false, false},
+		Verified:   {true, false},
+		Friend:     {true, true},
+		Invitee:    {true, true},
+		Host:       {true, true},
+	}
+
+	event := types.EventAddresses{
+		Id:             eventId,
+		HostId:         hostId,
+		ForUnloggedins: true,
+		FirstName:      "Ada",
+		LastName:       "Lovelace",
+		Address:        "1 Main St",
+		City:           "Springfield",
+	}
+
+	for kind, viewer := range viewers() {
+		seen, ok := viewer.See(event)
+		if !ok {
+			t.Fatalf("viewer %d can't see a public event", kind)
+		}
+
+		want := tests[kind]
+		if (seen.Address != "") != want.address {
+			t.Errorf("viewer %d got address %q", kind, seen.Address)
+		}
+
+		if (seen.FirstName != "" || seen.LastName != "") != want.hostName {
+			t.Errorf("viewer %d got host name %q %q", kind, seen.FirstName, seen.LastName)
+		}
+
+		if seen.City != event.City {
+			t.Errorf("viewer %d got city %q, want it kept", kind, seen.City)
+		}
+	}
+}
+
+func TestBlockedByHost(t *testing.T) {
+	event := types.EventAddresses{Id: eventId, HostId: hostId, ForUnloggedins: true}
+	otherEvent := types.EventAddresses{Id: eventId + 1, HostId: otherHost, ForUnloggedins: true}
+
+	for kind, viewer := range viewers() {
+		if kind == Anonymous || kind == Host {
+			continue
+		}
+		viewer.BlockedByIds[hostId] = true
+
+		if viewer.CanSee(types.Events{Id: eventId, HostId: hostId, ForUnloggedins: true}) {
+			t.Errorf("viewer %d can see an event by a host who blocked them", kind)
+		}
+
+		if _, ok := viewer.See(event); ok {
+			t.Errorf("viewer %d can see an event by a host who blocked them", kind)
+		}
+
+		if visible := viewer.Filter([]types.EventAddresses{event, otherEvent}); len(visible) != 1 || visible[0].Id != otherEvent.Id {
+			t.Errorf("viewer %d got %+v, want only the other host's event", kind, visible)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	events := []types.EventAddresses{
+		{Id: eventId, HostId: hostId, InviteOnly: true, Address: "1 Main St"},
+		{Id: eventId + 1, HostId: otherHost, ForUnloggedins: true, Address: "2 Main St", FirstName: "Ada"},
+	}
+
+	visible := viewers()[Verified].Filter(events)
+	if len(visible) != 1 || visible[0].Id != eventId+1 {
+		t.Fatalf("got %+v, want only the public event", visible)
+	}
+
+	if visible[0].Address != "2 Main St" || visible[0].FirstName != "" {
+		t.Errorf("got %+v, want the address kept and the host name redacted", visible[0])
+	}
+}
@@ -23,21 +23,36 @@ func NewHandler(store types.FriendStore, neighborStore types.NeighborStore) *Han
 
 func (h *Handler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/friends/auth", auth.WithJWTAuth(h.handleGetFriends, h.neighborStore)).Methods("GET")
+	router.HandleFunc("/friends/suggestions/auth", auth.WithJWTAuth(h.handleGetFriendSuggestions, h.neighborStore)).Methods("GET")
 	router.HandleFunc("/friend-requests/{requestedFriendId}/auth", auth.WithJWTAuth(h.handleCreateFriendRequest, h.neighborStore)).Methods("POST")
 	router.HandleFunc("/friend-requests/auth", auth.WithJWTAuth(h.handleGetFriendRequests, h.neighborStore)).Methods("GET")
 	router.HandleFunc("/friend-requests/{friendId}/{status}/auth", auth.WithJWTAuth(h.handlePutFriendRequest, h.neighborStore)).Methods("PUT")
+	router.HandleFunc("/friends/{friendId:[0-9]+}/auth", auth.WithJWTAuth(h.handleDeleteFriend, h.neighborStore)).Methods("DELETE")
+	router.HandleFunc("/friend-requests/{requestedFriendId:[0-9]+}/auth", auth.WithJWTAuth(h.handleDeleteFriendRequest, h.neighborStore)).Methods("DELETE")
+	router.HandleFunc("/blocks/auth", auth.WithJWTAuth(h.handleGetBlocks, h.neighborStore)).Methods("GET")
+	router.HandleFunc("/blocks/{neighborId:[0-9]+}/auth", auth.WithJWTAuth(h.handleCreateBlock, h.neighborStore)).Methods("POST")
+	router.HandleFunc("/blocks/{neighborId:[0-9]+}/auth", auth.WithJWTAuth(h.handleDeleteBlock, h.neighborStore)).Methods("DELETE")
 }
 
 func (h *Handler) handleGetFriends(w http.ResponseWriter, r *http.Request) {
 	neighborId := auth.GetNeighborIdFromContext(r.Context())
 
-	friends, err := h.store.GetFriendsByNeighborId(neighborId)
+	var cursor types.Cursor
+	limit, err := utils.ReadPagination(r.URL.Query(), &cursor)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	friends, err := h.store.GetFriendsByNeighborId(neighborId, types.Pagination{Limit: limit + 1, After: cursor})
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
 		return
 	}
 
-	utils.WriteJSON(w, http.StatusOK, friends)
+	utils.WritePage(w, http.StatusOK, friends, limit, func(friend types.FriendsList) any {
+		return types.Cursor{Key: friend.FirstName, Id: friend.Id}
+	})
 }
 
 func (h *Handler) handleCreateFriendRequest(w http.ResponseWriter, r *http.Request) {
@@ -55,17 +70,54 @@ func (h *Handler) handleCreateFriendRequest(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	err = h.store.CreateFriendRequest(types.FriendRequests{
+	if requestedFriendId == neighborId {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("you can't friend yourself"))
+		return
+	}
+
+	// a neighbor who blocked the requester looks like they aren't there
+	blocked, err := h.store.IsBlocked(requestedFriendId, neighborId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	if blocked {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("not found"))
+		return
+	}
+
+	blocking, err := h.store.IsBlocked(neighborId, requestedFriendId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	if blocking {
+		utils.WriteError(w, http.StatusConflict, fmt.Errorf("unblock this neighbor first"))
+		return
+	}
+
+	// asking someone who already asked you is the same as accepting
+	status, err := h.store.CreateFriendRequest(types.FriendRequests{
 		NeighborId:        neighborId,
 		RequestedFriendId: requestedFriendId,
-		Status:            "pending",
 	})
+	if utils.IsForeignKeyViolation(err) {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("not found"))
+		return
+	}
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
 		return
 	}
 
-	okStatus := map[string]string{"requestedFriendId": str}
+	if status == types.AlreadyFriends || status == types.AlreadyPending {
+		utils.WriteError(w, http.StatusConflict, fmt.Errorf("%s", status))
+		return
+	}
+
+	okStatus := map[string]string{"requestedFriendId": str, "status": status}
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(okStatus)
 }
@@ -73,13 +125,22 @@ func (h *Handler) handleCreateFriendRequest(w http.ResponseWriter, r *http.Reque
 func (h *Handler) handleGetFriendRequests(w http.ResponseWriter, r *http.Request) {
 	neighborId := auth.GetNeighborIdFromContext(r.Context())
 
-	friendRequests, err := h.store.GetFriendRequestsByNeighborId(neighborId)
+	var cursor types.Cursor
+	limit, err := utils.ReadPagination(r.URL.Query(), &cursor)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	friendRequests, err := h.store.GetFriendRequestsByNeighborId(neighborId, types.Pagination{Limit: limit + 1, After: cursor})
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
 		return
 	}
 
-	utils.WriteJSON(w, http.StatusOK, friendRequests)
+	utils.WritePage(w, http.StatusOK, friendRequests, limit, func(friendRequest types.PendingFriendRequests) any {
+		return types.Cursor{Key: friendRequest.Username, Id: friendRequest.FriendRequestId}
+	})
 }
 
 func (h *Handler) handlePutFriendRequest(w http.ResponseWriter, r *http.Request) {
@@ -103,22 +164,17 @@ func (h *Handler) handlePutFriendRequest(w http.ResponseWriter, r *http.Request)
 	}
 
 	if str1 == "accepted" {
-		err = h.store.UpdateFriendRequest(types.FriendRequests{
+		accepted, err := h.store.AcceptFriendRequest(types.FriendRequests{
 			NeighborId:        friendId,
 			RequestedFriendId: neighborId,
-			Status:            str1,
 		})
 		if err != nil {
 			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
 			return
 		}
 
-		err = h.store.CreateFriend(types.Friends{
-			NeighborId:        neighborId,
-			NeighborsFriendId: friendId,
-		})
-		if err != nil {
-			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		if !accepted {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("not found"))
 			return
 		}
 
@@ -127,7 +183,7 @@ func (h *Handler) handlePutFriendRequest(w http.ResponseWriter, r *http.Request)
 		json.NewEncoder(w).Encode(okStatus)
 
 	} else if str1 == "declined" {
-		err = h.store.UpdateFriendRequest(types.FriendRequests{
+		declined, err := h.store.UpdateFriendRequest(types.FriendRequests{
 			NeighborId:        friendId,
 			RequestedFriendId: neighborId,
 			Status:            str1,
@@ -137,6 +193,11 @@ func (h *Handler) handlePutFriendRequest(w http.ResponseWriter, r *http.Request)
 			return
 		}
 
+		if !declined {
+			utils.WriteError(w, http.StatusNotFound, fmt.Errorf("not found"))
+			return
+		}
+
 		okStatus := map[string]string{"friendId": str}
 		w.WriteHeader(http.StatusAccepted)
 		json.NewEncoder(w).Encode(okStatus)
@@ -145,3 +206,105 @@ func (h *Handler) handlePutFriendRequest(w http.ResponseWriter, r *http.Request)
 		return
 	}
 }
+
+// removes the friendship from both sides
+func (h *Handler) handleDeleteFriend(w http.ResponseWriter, r *http.Request) {
+	friendId, err := strconv.Atoi(mux.Vars(r)["friendId"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return
+	}
+
+	deleted, err := h.store.DeleteFriend(auth.GetNeighborIdFromContext(r.Context()), friendId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	if !deleted {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("not found"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// withdraws a request the neighbor sent that hasn't been answered yet
+func (h *Handler) handleDeleteFriendRequest(w http.ResponseWriter, r *http.Request) {
+	requestedFriendId, err := strconv.Atoi(mux.Vars(r)["requestedFriendId"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return
+	}
+
+	deleted, err := h.store.DeleteFriendRequest(auth.GetNeighborIdFromContext(r.Context()), requestedFriendId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	if !deleted {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("not found"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) handleGetBlocks(w http.ResponseWriter, r *http.Request) {
+	blocks, err := h.store.GetBlocksByNeighborId(auth.GetNeighborIdFromContext(r.Context()))
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, blocks)
+}
+
+func (h *Handler) handleCreateBlock(w http.ResponseWriter, r *http.Request) {
+	neighborId := auth.GetNeighborIdFromContext(r.Context())
+
+	blockedNeighborId, err := strconv.Atoi(mux.Vars(r)["neighborId"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return
+	}
+
+	if blockedNeighborId == neighborId {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("you can't block yourself"))
+		return
+	}
+
+	err = h.store.CreateBlock(types.Blocks{NeighborId: neighborId, BlockedNeighborId: blockedNeighborId})
+	if utils.IsForeignKeyViolation(err) {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("not found"))
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) handleDeleteBlock(w http.ResponseWriter, r *http.Request) {
+	blockedNeighborId, err := strconv.Atoi(mux.Vars(r)["neighborId"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return
+	}
+
+	deleted, err := h.store.DeleteBlock(auth.GetNeighborIdFromContext(r.Context()), blockedNeighborId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	if !deleted {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("not found"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,65 @@
+package friends
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/jamesdavidyu/neighborhost-service/cmd/model/types"
+	"github.com/jamesdavidyu/neighborhost-service/services/auth"
+	"github.com/jamesdavidyu/neighborhost-service/utils"
+)
+
+func (h *Handler) handleGetFriendSuggestions(w http.ResponseWriter, r *http.Request) {
+	var cursor *types.SuggestionCursor
+	limit, err := utils.ReadPagination(r.URL.Query(), &cursor)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	suggestions, err := h.store.GetFriendSuggestions(auth.GetNeighborIdFromContext(r.Context()), limit+1, cursor)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	for i := range suggestions {
+		suggestions[i].Reasons = reasons(suggestions[i])
+	}
+
+	utils.WritePage(w, http.StatusOK, suggestions, limit, func(suggestion types.FriendSuggestions) any {
+		return types.SuggestionCursor{Score: suggestion.Score, Id: suggestion.NeighborId}
+	})
+}
+
+// what the suggestion is based on, strongest first
+func reasons(suggestion types.FriendSuggestions) []string {
+	reasons := make([]string, 0)
+	if suggestion.MutualFriends > 0 {
+		reasons = append(reasons, plural(suggestion.MutualFriends, "mutual friend"))
+	}
+
+	if suggestion.SharedEvents > 0 {
+		reasons = append(reasons, fmt.Sprintf("went to %s with you", plural(suggestion.SharedEvents, "event")))
+	}
+
+	if suggestion.SameNeighborhood {
+		reasons = append(reasons, "lives in your neighborhood")
+	} else if suggestion.SameZipcode {
+		reasons = append(reasons, "lives in your zipcode")
+	}
+
+	if suggestion.SharedInterests > 0 {
+		reasons = append(reasons, fmt.Sprintf("shares %s with you", plural(suggestion.SharedInterests, "interest")))
+	}
+
+	return reasons
+}
+
+func plural(count int, noun string) string {
+	if count == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+
+	return fmt.Sprintf("%d %ss", count, noun)
+}
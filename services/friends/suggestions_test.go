@@ -0,0 +1,33 @@
+package friends
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jamesdavidyu/neighborhost-service/cmd/model/types"
+)
+
+func TestReasons(t *testing.T) {
+	tests := []struct {
+		name       string
+		suggestion types.FriendSuggestions
+		want       []string
+	}{
+		{"nothing", types.FriendSuggestions{}, []string{}},
+		{"one mutual friend", types.FriendSuggestions{MutualFriends: 1}, []string{"1 mutual friend"}},
+		{
+			"everything",
+			types.FriendSuggestions{MutualFriends: 2, SharedEvents: 3, SameNeighborhood: true, SameZipcode: true, SharedInterests: 1},
+			[]string{"2 mutual friends", "went to 3 events with you", "lives in your neighborhood", "shares 1 interest with you"},
+		},
+		{"zipcode only", types.FriendSuggestions{SameZipcode: true}, []string{"lives in your zipcode"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := reasons(test.suggestion); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package images
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// maxPixels keeps small files that decode into huge images from eating the server's memory
+const maxPixels = 40_000_000
+
+var (
+	ErrUnsupportedType = errors.New("only jpeg and png images are supported")
+	ErrTooLarge        = errors.New("image dimensions are too large")
+)
+
+// Size is the box an image is scaled down to fit in, smaller images are left as they are
+type Size struct {
+	Width  int
+	Height int
+}
+
+type Processed struct {
+	ContentType string
+	Image       []byte
+	Thumbnail   []byte
+	Width       int
+	Height      int
+}
+
+// Process checks what the upload really is and re-encodes it from its pixels,
+// which drops exif and every other bit of metadata, gps location included
+func Process(data []byte, size Size, thumbnailSize Size) (*Processed, error) {
+	contentType := mimetype.Detect(data).String()
+	if contentType != "image/jpeg" && contentType != "image/png" {
+		return nil, ErrUnsupportedType
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedType
+	}
+
+	if config.Width*config.Height > maxPixels {
+		return nil, ErrTooLarge
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedType
+	}
+
+	pixels := image.NewRGBA(image.Rect(0, 0, decoded.Bounds().Dx(), decoded.Bounds().Dy()))
+	draw.Draw(pixels, pixels.Bounds(), decoded, decoded.Bounds().Min, draw.Src)
+
+	if contentType == "image/jpeg" {
+		pixels = orient(pixels, orientation(data))
+	}
+
+	full := fit(pixels, size)
+
+	imageData, err := encode(full, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	thumbnailData, err := encode(fit(full, thumbnailSize), contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Processed{
+		ContentType: contentType,
+		Image:       imageData,
+		Thumbnail:   thumbnailData,
+		Width:       full.Bounds().Dx(),
+		Height:      full.Bounds().Dy(),
+	}, nil
+}
+
+func encode(img image.Image, contentType string) ([]byte, error) {
+	var buf bytes.Buffer
+
+	var err error
+	if contentType == "image/png" {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// fit scales down keeping the aspect ratio
+func fit(src *image.RGBA, size Size) *image.RGBA {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	if w <= size.Width && h <= size.Height {
+		return src
+	}
+
+	dw, dh := size.Width, h*size.Width/w
+	if dh > size.Height {
+		dw, dh = w*size.Height/h, size.Height
+	}
+
+	return resize(src, max(dw, 1), max(dh, 1))
+}
+
+// resize averages the block of source pixels under each destination pixel, which is good enough for shrinking.
+// the pixels are premultiplied so transparent edges don't bleed dark
+func resize(src *image.RGBA, dw int, dh int) *image.RGBA {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	for dy := 0; dy < dh; dy++ {
+		y0, y1 := dy*sh/dh, max((dy+1)*sh/dh, dy*sh/dh+1)
+		for dx := 0; dx < dw; dx++ {
+			x0, x1 := dx*sw/dw, max((dx+1)*sw/dw, dx*sw/dw+1)
+
+			var r, g, b, a, n int
+			for y := y0; y < y1; y++ {
+				i := src.PixOffset(x0+src.Rect.Min.X, y+src.Rect.Min.Y)
+				for x := x0; x < x1; x++ {
+					r += int(src.Pix[i])
+					g += int(src.Pix[i+1])
+					b += int(src.Pix[i+2])
+					a += int(src.Pix[i+3])
+					n++
+					i += 4
+				}
+			}
+
+			d := dst.PixOffset(dx, dy)
+			dst.Pix[d] = uint8(r / n)
+			dst.Pix[d+1] = uint8(g / n)
+			dst.Pix[d+2] = uint8(b / n)
+			dst.Pix[d+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
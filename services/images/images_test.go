@@ -0,0 +1,222 @@
+package images
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+var (
+	red  = color.RGBA{255, 0, 0, 255}
+	blue = color.RGBA{0, 0, 255, 255}
+)
+
+// halves is red on the left and blue on the right
+func halves(w int, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if x < w/2 {
+				img.Set(x, y, red)
+			} else {
+				img.Set(x, y, blue)
+			}
+		}
+	}
+
+	return img
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 100}); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+const gpsMarker = "GPS 51.5007N 0.1246W"
+
+// withExif puts an app1 segment right after the jpeg's start marker with the orientation,
+// a gps ifd pointer and some gps looking text for the test to look for afterwards
+func withExif(data []byte, orientation int) []byte {
+	order := binary.LittleEndian
+	tiff := []byte("II*\x00")
+	tiff = order.AppendUint32(tiff, 8)
+
+	tiff = order.AppendUint16(tiff, 2)
+	tiff = order.AppendUint16(tiff, 0x0112) // orientation
+	tiff = order.AppendUint16(tiff, 3)
+	tiff = order.AppendUint32(tiff, 1)
+	tiff = order.AppendUint16(tiff, uint16(orientation))
+	tiff = order.AppendUint16(tiff, 0)
+	tiff = order.AppendUint16(tiff, 0x8825) // gps ifd
+	tiff = order.AppendUint16(tiff, 4)
+	tiff = order.AppendUint32(tiff, 1)
+	tiff = order.AppendUint32(tiff, uint32(len(tiff)+8))
+	tiff = order.AppendUint32(tiff, 0)
+	tiff = append(tiff, gpsMarker...)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1}
+	app1 = binary.BigEndian.AppendUint16(app1, uint16(len(segment)+2))
+	app1 = append(app1, segment...)
+
+	return append(append([]byte{0xFF, 0xD8}, app1...), data[2:]...)
+}
+
+func isRed(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+	return r > 0xC000 && g < 0x4000 && b < 0x4000
+}
+
+func TestProcessRejectsOtherTypes(t *testing.T) {
+	var gifData bytes.Buffer
+	if err := gif.Encode(&gifData, halves(8, 8), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string][]byte{
+		"gif":            gifData.Bytes(),
+		"text":           []byte("just some text"),
+		"svg":            []byte(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`),
+		"broken jpeg":    append([]byte{0xFF, 0xD8, 0xFF, 0xE0}, bytes.Repeat([]byte{0}, 32)...),
+		"empty":          {},
+		"jpeg extension": []byte("GIF89a pretending"),
+	}
+
+	for name, data := range tests {
+		if _, err := Process(data, Size{100, 100}, Size{10, 10}); !errors.Is(err, ErrUnsupportedType) {
+			t.Errorf("%s: got %v, want ErrUnsupportedType", name, err)
+		}
+	}
+}
+
+func TestProcessStripsMetadata(t *testing.T) {
+	data := withExif(encodeJPEG(t, halves(16, 8)), 1)
+	if orientation(data) != 1 || !bytes.Contains(data, []byte(gpsMarker)) {
+		t.Fatal("the test image doesn't carry the exif it should")
+	}
+
+	processed, err := Process(data, Size{100, 100}, Size{10, 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, out := range map[string][]byte{"image": processed.Image, "thumbnail": processed.Thumbnail} {
+		if bytes.Contains(out, []byte("Exif")) || bytes.Contains(out, []byte(gpsMarker)) {
+			t.Errorf("the %s kept its exif", name)
+		}
+	}
+}
+
+func TestProcessStripsPNGText(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, halves(16, 8)); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	// a text chunk can go anywhere before the end chunk
+	body := append([]byte("tEXt"), "Comment\x00"+gpsMarker...)
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(body)-4))
+	chunk = binary.BigEndian.AppendUint32(append(chunk, body...), crc32.ChecksumIEEE(body))
+	end := len(data) - 12
+	data = append(append(append([]byte{}, data[:end]...), chunk...), data[end:]...)
+
+	processed, err := Process(data, Size{100, 100}, Size{10, 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Contains(data, []byte(gpsMarker)) || processed.ContentType != "image/png" || bytes.Contains(processed.Image, []byte(gpsMarker)) {
+		t.Errorf("got %s, want a png without the text chunk", processed.ContentType)
+	}
+}
+
+func TestProcessOrientation(t *testing.T) {
+	// where the red left half of the stored image ends up once it's displayed the right way
+	tests := []struct {
+		orientation int
+		width       int
+		height      int
+		redAt       image.Point
+		blueAt      image.Point
+	}{
+		{1, 32, 16, image.Pt(4, 8), image.Pt(28, 8)},
+		{2, 32, 16, image.Pt(28, 8), image.Pt(4, 8)},
+		{3, 32, 16, image.Pt(28, 8), image.Pt(4, 8)},
+		{4, 32, 16, image.Pt(4, 8), image.Pt(28, 8)},
+		{5, 16, 32, image.Pt(8, 4), image.Pt(8, 28)},
+		{6, 16, 32, image.Pt(8, 4), image.Pt(8, 28)},
+		{7, 16, 32, image.Pt(8, 28), image.Pt(8, 4)},
+		{8, 16, 32, image.Pt(8, 28), image.Pt(8, 4)},
+	}
+
+	for _, test := range tests {
+		processed, err := Process(withExif(encodeJPEG(t, halves(32, 16)), test.orientation), Size{100, 100}, Size{10, 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		img, err := jpeg.Decode(bytes.NewReader(processed.Image))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if processed.Width != test.width || processed.Height != test.height {
+			t.Errorf("orientation %d: got %dx%d, want %dx%d", test.orientation, processed.Width, processed.Height, test.width, test.height)
+		}
+
+		if !isRed(img.At(test.redAt.X, test.redAt.Y)) || isRed(img.At(test.blueAt.X, test.blueAt.Y)) {
+			t.Errorf("orientation %d: the red half isn't around %v", test.orientation, test.redAt)
+		}
+	}
+}
+
+func TestProcessSizes(t *testing.T) {
+	tests := []struct {
+		name      string
+		width     int
+		height    int
+		size      Size
+		thumbnail Size
+		want      image.Point
+		wantThumb image.Point
+	}{
+		{"wide", 400, 200, Size{100, 100}, Size{20, 20}, image.Pt(100, 50), image.Pt(20, 10)},
+		{"tall", 200, 400, Size{100, 100}, Size{20, 20}, image.Pt(50, 100), image.Pt(10, 20)},
+		{"small enough", 60, 30, Size{100, 100}, Size{20, 20}, image.Pt(60, 30), image.Pt(20, 10)},
+		{"smaller than the thumbnail", 10, 10, Size{100, 100}, Size{20, 20}, image.Pt(10, 10), image.Pt(10, 10)},
+	}
+
+	for _, test := range tests {
+		processed, err := Process(encodeJPEG(t, halves(test.width, test.height)), test.size, test.thumbnail)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := image.Pt(processed.Width, processed.Height); got != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+
+		thumbnail, _, err := image.DecodeConfig(bytes.NewReader(processed.Thumbnail))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := image.Pt(thumbnail.Width, thumbnail.Height); got != test.wantThumb {
+			t.Errorf("%s: got a %v thumbnail, want %v", test.name, got, test.wantThumb)
+		}
+	}
+}
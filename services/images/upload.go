@@ -0,0 +1,175 @@
+package images
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/jamesdavidyu/neighborhost-service/cmd/model/types"
+	"github.com/jamesdavidyu/neighborhost-service/config"
+	"github.com/jamesdavidyu/neighborhost-service/utils"
+)
+
+var (
+	AvatarSize          = Size{Width: 1024, Height: 1024}
+	AvatarThumbnailSize = Size{Width: 128, Height: 128}
+	CoverSize           = Size{Width: 2048, Height: 2048}
+	CoverThumbnailSize  = Size{Width: 640, Height: 640}
+)
+
+// Upload processes the multipart "image" field and stores it and its thumbnail under prefix,
+// it writes the error response itself when the upload can't be used
+func Upload(w http.ResponseWriter, r *http.Request, blobStore types.BlobStore, prefix string, size Size, thumbnailSize Size) (*types.Images, bool) {
+	r.Body = http.MaxBytesReader(w, r.Body, config.Envs.MaxUploadSizeInBytes)
+
+	file, _, err := r.FormFile("image")
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			utils.WriteError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("image is too large"))
+			return nil, false
+		}
+
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return nil, false
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return nil, false
+	}
+
+	processed, err := Process(data, size, thumbnailSize)
+	if errors.Is(err, ErrUnsupportedType) {
+		utils.WriteError(w, http.StatusUnsupportedMediaType, err)
+		return nil, false
+	}
+	if errors.Is(err, ErrTooLarge) {
+		utils.WriteError(w, http.StatusRequestEntityTooLarge, err)
+		return nil, false
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("image error"))
+		return nil, false
+	}
+
+	name, err := randomName()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("image error"))
+		return nil, false
+	}
+
+	extension := ".jpg"
+	if processed.ContentType == "image/png" {
+		extension = ".png"
+	}
+
+	image := &types.Images{
+		BlobKey:      prefix + "/" + name + extension,
+		ThumbnailKey: prefix + "/" + name + "_thumbnail" + extension,
+		ContentType:  processed.ContentType,
+		Width:        processed.Width,
+		Height:       processed.Height,
+	}
+
+	if err := blobStore.PutBlob(image.BlobKey, image.ContentType, processed.Image); err != nil {
+		log.Printf("storing %s: %v", image.BlobKey, err)
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("storage error"))
+		return nil, false
+	}
+
+	if err := blobStore.PutBlob(image.ThumbnailKey, image.ContentType, processed.Thumbnail); err != nil {
+		log.Printf("storing %s: %v", image.ThumbnailKey, err)
+		Discard(blobStore, image)
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("storage error"))
+		return nil, false
+	}
+
+	return image, true
+}
+
+// Discard removes the blobs of an image that never made it into the database
+func Discard(blobStore types.BlobStore, image *types.Images) {
+	for _, key := range []string{image.BlobKey, image.ThumbnailKey} {
+		if err := blobStore.DeleteBlob(key); err != nil {
+			log.Printf("discarding %s: %v", key, err)
+		}
+	}
+}
+
+// Serve writes the image, or its thumbnail with ?size=thumbnail
+func Serve(w http.ResponseWriter, r *http.Request, blobStore types.BlobStore, image *types.Images) {
+	key := image.BlobKey
+	if r.URL.Query().Get("size") == "thumbnail" {
+		key = image.ThumbnailKey
+	}
+
+	blob, err := blobStore.GetBlob(key)
+	if errors.Is(err, fs.ErrNotExist) {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("not found"))
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("storage error"))
+		return
+	}
+	defer blob.Close()
+
+	// keys change with every upload, so a cached image never goes stale
+	w.Header().Set("Content-Type", image.ContentType)
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := io.Copy(w, blob); err != nil {
+		log.Printf("serving %s: %v", key, err)
+	}
+}
+
+// StartSweep deletes images nothing links to anymore, checking every interval until the process exits.
+// the row goes last so a blob that fails to delete is tried again on the next sweep
+func StartSweep(imageStore types.ImageStore, blobStore types.BlobStore, interval time.Duration) {
+	go func() {
+		for {
+			orphans, err := imageStore.GetOrphanedImages(100)
+			if err != nil {
+				log.Printf("listing orphaned images: %v", err)
+			}
+
+			for _, orphan := range orphans {
+				if err := blobStore.DeleteBlob(orphan.BlobKey); err != nil {
+					log.Printf("sweeping %s: %v", orphan.BlobKey, err)
+					continue
+				}
+
+				if err := blobStore.DeleteBlob(orphan.ThumbnailKey); err != nil {
+					log.Printf("sweeping %s: %v", orphan.ThumbnailKey, err)
+					continue
+				}
+
+				if err := imageStore.DeleteImage(orphan.Id); err != nil {
+					log.Printf("sweeping image %d: %v", orphan.Id, err)
+				}
+			}
+
+			time.Sleep(interval)
+		}
+	}()
+}
+
+func randomName() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,98 @@
+package mail
+
+import (
+	"fmt"
+	"log"
+	netmail "net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jamesdavidyu/neighborhost-service/config"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(Message) error
+}
+
+// NewMailer sends through smtp when SMTP_HOST is set, otherwise mail is only logged and written to MAIL_DIR
+func NewMailer() Mailer {
+	if config.Envs.SMTPHost == "" {
+		return &LogMailer{Dir: config.Envs.MailDir}
+	}
+
+	return &SMTPMailer{
+		Host:     config.Envs.SMTPHost,
+		Port:     config.Envs.SMTPPort,
+		Username: config.Envs.SMTPUsername,
+		Password: config.Envs.SMTPPassword,
+		From:     config.Envs.MailFrom,
+	}
+}
+
+type SMTPMailer struct {
+	Host     string
+	Port     int64
+	Username string
+	Password string
+	From     string
+}
+
+func (m *SMTPMailer) Send(message Message) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	// the envelope sender is the bare address, the header keeps the display name
+	from := m.From
+	if address, err := netmail.ParseAddress(m.From); err == nil {
+		from = address.Address
+	}
+
+	return smtp.SendMail(fmt.Sprintf("%s:%d", m.Host, m.Port), auth, from, []string{message.To}, format(m.From, message))
+}
+
+// LogMailer is for local development and tests, Dir is left empty to only log
+type LogMailer struct {
+	Dir string
+}
+
+func (m *LogMailer) Send(message Message) error {
+	log.Printf("mail to %s: %s\n%s", message.To, message.Subject, message.Body)
+
+	if m.Dir == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), strings.NewReplacer("@", "_at_", "/", "_").Replace(message.To))
+
+	return os.WriteFile(filepath.Join(m.Dir, name), format(config.Envs.MailFrom, message), 0o644)
+}
+
+// header values have line breaks stripped so they can't add headers of their own
+func format(from string, message Message) []byte {
+	header := strings.NewReplacer("\r", "", "\n", "").Replace
+	headers := []string{
+		"From: " + header(from),
+		"To: " + header(message.To),
+		"Subject: " + header(message.Subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=utf-8",
+	}
+
+	return []byte(strings.Join(headers, "\r\n") + "\r\n\r\n" + strings.ReplaceAll(message.Body, "\n", "\r\n"))
+}
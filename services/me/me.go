@@ -0,0 +1,261 @@
+package me
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/jamesdavidyu/neighborhost-service/cmd/model/types"
+	"github.com/jamesdavidyu/neighborhost-service/config"
+	"github.com/jamesdavidyu/neighborhost-service/services/auth"
+	"github.com/jamesdavidyu/neighborhost-service/services/mail"
+	neighborServices "github.com/jamesdavidyu/neighborhost-service/services/neighbors"
+	"github.com/jamesdavidyu/neighborhost-service/utils"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type Handler struct {
+	store             types.NeighborStore
+	addressStore      types.AddressStore
+	eventStore        types.EventStore
+	friendStore       types.FriendStore
+	notificationStore types.NotificationStore
+	profileStore      types.ProfileStore
+	tokenStore        types.TokenStore
+	mailer            mail.Mailer
+}
+
+func NewHandler(store types.NeighborStore, addressStore types.AddressStore, eventStore types.EventStore, friendStore types.FriendStore, notificationStore types.NotificationStore, profileStore types.ProfileStore, tokenStore types.TokenStore, mailer mail.Mailer) *Handler {
+	return &Handler{store: store, addressStore: addressStore, eventStore: eventStore, friendStore: friendStore, notificationStore: notificationStore, profileStore: profileStore, tokenStore: tokenStore, mailer: mailer}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/me/auth", auth.WithJWTAuth(h.handleGetMe, h.store)).Methods("GET")
+	router.HandleFunc("/me/auth", auth.WithJWTAuth(h.handlePatchMe, h.store)).Methods("PATCH")
+	router.HandleFunc("/me/export/auth", auth.WithJWTAuth(h.handleGetExport, h.store)).Methods("GET")
+	router.HandleFunc("/me/auth", auth.WithJWTAuth(h.handleDeleteMe, h.store)).Methods("DELETE")
+}
+
+func (h *Handler) handleGetMe(w http.ResponseWriter, r *http.Request) {
+	neighbor, err := h.store.GetNeighborById(auth.GetNeighborIdFromContext(r.Context()))
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, neighbor)
+}
+
+// a new email has to be verified again, the link goes to the new address
+func (h *Handler) handlePatchMe(w http.ResponseWriter, r *http.Request) {
+	neighborId := auth.GetNeighborIdFromContext(r.Context())
+
+	var payload types.UpdateMePayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid submission for %v", errors))
+		return
+	}
+
+	neighbor, err := h.store.GetNeighborById(neighborId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	emailChanged := payload.Email != nil && *payload.Email != neighbor.Email
+	usernameChanged := payload.Username != nil && *payload.Username != neighbor.Username
+
+	if emailChanged || usernameChanged {
+		if bcrypt.CompareHashAndPassword([]byte(neighbor.Password), []byte(payload.CurrentPassword)) != nil {
+			utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("incorrect password"))
+			return
+		}
+	}
+
+	updated := *neighbor
+	if emailChanged {
+		updated.Email = *payload.Email
+		updated.Verified = false
+	}
+	if usernameChanged {
+		updated.Username = *payload.Username
+	}
+	if payload.Zipcode != nil {
+		updated.Zipcode = *payload.Zipcode
+	}
+
+	email, username := "", ""
+	if emailChanged {
+		email = updated.Email
+	}
+	if usernameChanged {
+		username = updated.Username
+	}
+
+	reason, err := neighborServices.Taken(h.store, neighborId, email, username)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	if reason != "" {
+		utils.WriteError(w, http.StatusConflict, fmt.Errorf("%s", reason))
+		return
+	}
+
+	err = h.store.UpdateAccountWithId(updated)
+	if utils.IsUniqueViolation(err) {
+		utils.WriteError(w, http.StatusConflict, fmt.Errorf("email and/or username taken"))
+		return
+	}
+	if utils.IsForeignKeyViolation(err) {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("unknown zipcode"))
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	if emailChanged {
+		if err := neighborServices.SendVerification(h.tokenStore, h.mailer, &updated); err != nil {
+			log.Printf("sending verification to neighbor %d: %v", neighborId, err)
+		}
+	}
+
+	utils.WriteJSON(w, http.StatusOK, updated)
+}
+
+func (h *Handler) handleGetExport(w http.ResponseWriter, r *http.Request) {
+	neighborId := auth.GetNeighborIdFromContext(r.Context())
+
+	export, err := h.export(neighborId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="neighborhost-export-%d.json"`, neighborId))
+	utils.WriteJSON(w, http.StatusOK, export)
+}
+
+// an empty page limit reads every row
+func (h *Handler) export(neighborId int) (*types.NeighborExport, error) {
+	neighbor, err := h.store.GetNeighborById(neighborId)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &types.NeighborExport{ExportedAt: time.Now(), Neighbor: *neighbor}
+
+	if export.Addresses, err = h.addressStore.GetAddressesByNeighborId(neighborId, types.Pagination{}); err != nil {
+		return nil, err
+	}
+
+	if export.HostedEvents, err = h.eventStore.GetEventsByHostId(neighborId); err != nil {
+		return nil, err
+	}
+
+	if export.Rsvps, err = h.eventStore.GetRsvpsByNeighborId(neighborId); err != nil {
+		return nil, err
+	}
+
+	if export.EventInvites, err = h.eventStore.GetEventInvitesByNeighborId(neighborId); err != nil {
+		return nil, err
+	}
+
+	if export.Friends, err = h.friendStore.GetFriendshipsByNeighborId(neighborId); err != nil {
+		return nil, err
+	}
+
+	if export.FriendRequests, err = h.friendStore.GetAllFriendRequestsByNeighborId(neighborId); err != nil {
+		return nil, err
+	}
+
+	if export.Notifications, err = h.notificationStore.GetNotificationsByNeighborId(neighborId, types.Pagination{}); err != nil {
+		return nil, err
+	}
+
+	if export.Logins, err = h.store.GetLoginsByNeighborId(neighborId, types.Pagination{}); err != nil {
+		return nil, err
+	}
+
+	profile, err := h.profileStore.GetProfileByNeighborId(neighborId)
+	if err != nil {
+		return nil, err
+	}
+	export.Profile = *profile
+
+	if export.ProfileHistory, err = h.profileStore.GetProfileHistoryByNeighborId(neighborId); err != nil {
+		return nil, err
+	}
+
+	if export.Blocks, err = h.friendStore.GetBlocksByNeighborId(neighborId); err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
+
+// logging back in before purgeAfter cancels the deletion
+func (h *Handler) handleDeleteMe(w http.ResponseWriter, r *http.Request) {
+	neighborId := auth.GetNeighborIdFromContext(r.Context())
+
+	var payload types.DeleteAccountPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid submission for %v", errors))
+		return
+	}
+
+	neighbor, err := h.store.GetNeighborById(neighborId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(neighbor.Password), []byte(payload.Password)) != nil {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("incorrect password"))
+		return
+	}
+
+	gracePeriod := time.Duration(config.Envs.AccountDeletionGraceInSeconds) * time.Second
+	deletion, err := h.store.ScheduleDeletion(neighborId, gracePeriod)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusAccepted, deletion)
+}
+
+// StartPurge hard deletes neighbors whose grace period is over, checking every interval until the process exits
+func StartPurge(store types.NeighborStore, interval time.Duration) {
+	go func() {
+		for {
+			purged, err := store.PurgeDueDeletions()
+			if err != nil {
+				log.Printf("purging deleted neighbors: %v", err)
+			} else if purged > 0 {
+				log.Printf("purged %d deleted neighbors", purged)
+			}
+
+			time.Sleep(interval)
+		}
+	}()
+}
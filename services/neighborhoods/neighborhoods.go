@@ -1,9 +1,12 @@
 package neighborhoods
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 
+	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
 	"github.com/jamesdavidyu/neighborhost-service/cmd/model/types"
 	"github.com/jamesdavidyu/neighborhost-service/services/auth"
@@ -21,14 +24,141 @@ func NewHandler(store types.NeighborhoodStore, neighborStore types.NeighborStore
 
 func (h *Handler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/neighborhoods", auth.WithJWTAuth(h.handleGetNeighborhoods, h.neighborStore)).Methods("GET")
+	router.HandleFunc("/neighborhoods/auth", auth.WithJWTAuth(auth.RequirePermission(h.handleCreateNeighborhood, h.neighborStore, auth.ManageNeighborhoods, nil), h.neighborStore)).Methods("POST")
+	router.HandleFunc("/neighborhoods/{neighborhoodId:[0-9]+}/moderators/auth", auth.WithJWTAuth(h.handleGetModerators, h.neighborStore)).Methods("GET")
+	router.HandleFunc("/neighborhoods/{neighborhoodId:[0-9]+}/moderators/auth", auth.WithJWTAuth(auth.RequirePermission(h.handleCreateModerator, h.neighborStore, auth.ManageRoles, auth.NeighborhoodFromVars), h.neighborStore)).Methods("POST")
+	router.HandleFunc("/neighborhoods/{neighborhoodId:[0-9]+}/moderators/{neighborId:[0-9]+}/auth", auth.WithJWTAuth(auth.RequirePermission(h.handleDeleteModerator, h.neighborStore, auth.ManageRoles, auth.NeighborhoodFromVars), h.neighborStore)).Methods("DELETE")
 }
 
 func (h *Handler) handleGetNeighborhoods(w http.ResponseWriter, r *http.Request) {
-	neighborhoods, err := h.store.GetNeighborhoods()
+	var cursor types.Cursor
+	limit, err := utils.ReadPagination(r.URL.Query(), &cursor)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	neighborhoods, err := h.store.GetNeighborhoods(types.Pagination{Limit: limit + 1, After: cursor})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	utils.WritePage(w, http.StatusOK, neighborhoods, limit, func(neighborhood types.Neighborhoods) any {
+		return types.Cursor{Id: neighborhood.Id}
+	})
+}
+
+func (h *Handler) handleCreateNeighborhood(w http.ResponseWriter, r *http.Request) {
+	var payload types.NeighborhoodPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid submission for %v", errors))
+		return
+	}
+
+	err := h.store.CreateNeighborhood(types.Neighborhoods{Neighborhood: payload.Neighborhood})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (h *Handler) handleGetModerators(w http.ResponseWriter, r *http.Request) {
+	neighborhood, ok := h.getNeighborhoodFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	moderators, err := h.store.GetModerators(neighborhood.Id)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
 		return
 	}
 
-	utils.WriteJSON(w, http.StatusOK, neighborhoods)
+	utils.WriteJSON(w, http.StatusOK, moderators)
+}
+
+func (h *Handler) handleCreateModerator(w http.ResponseWriter, r *http.Request) {
+	var payload types.ModeratorPayload
+
+	neighborhood, ok := h.getNeighborhoodFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid submission for %v", errors))
+		return
+	}
+
+	if _, err := h.neighborStore.GetNeighborById(payload.NeighborId); err != nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("not found"))
+		return
+	}
+
+	err := h.store.CreateModerator(types.NeighborhoodModerators{
+		NeighborhoodId: neighborhood.Id,
+		NeighborId:     payload.NeighborId,
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (h *Handler) handleDeleteModerator(w http.ResponseWriter, r *http.Request) {
+	neighborhood, ok := h.getNeighborhoodFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	neighborId, err := strconv.Atoi(mux.Vars(r)["neighborId"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return
+	}
+
+	if err := h.store.DeleteModerator(neighborhood.Id, neighborId); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) getNeighborhoodFromRequest(w http.ResponseWriter, r *http.Request) (*types.Neighborhoods, bool) {
+	neighborhoodId, err := auth.NeighborhoodFromVars(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return nil, false
+	}
+
+	neighborhood, err := h.store.GetNeighborhoodById(neighborhoodId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return nil, false
+	}
+
+	if neighborhood.Id == 0 {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("not found"))
+		return nil, false
+	}
+
+	return neighborhood, true
 }
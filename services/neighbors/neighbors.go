@@ -3,29 +3,62 @@ package neighbors
 import (
 	"encoding/json"
 	"fmt"
+	"log"
+	"math"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
 	"github.com/jamesdavidyu/neighborhost-service/cmd/model/types"
 	"github.com/jamesdavidyu/neighborhost-service/config"
 	"github.com/jamesdavidyu/neighborhost-service/services/auth"
+	"github.com/jamesdavidyu/neighborhost-service/services/mail"
 	"github.com/jamesdavidyu/neighborhost-service/utils"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type Handler struct {
-	store types.NeighborStore
+	store          types.NeighborStore
+	tokenStore     types.TokenStore
+	twoFactorStore types.TwoFactorStore
+	mailer         mail.Mailer
+	limiter        *auth.LoginLimiter
 }
 
-func NewHandler(store types.NeighborStore) *Handler {
-	return &Handler{store: store}
+func NewHandler(store types.NeighborStore, tokenStore types.TokenStore, loginAttemptStore types.LoginAttemptStore, twoFactorStore types.TwoFactorStore, mailer mail.Mailer) *Handler {
+	return &Handler{store: store, tokenStore: tokenStore, twoFactorStore: twoFactorStore, mailer: mailer, limiter: auth.NewLoginLimiter(loginAttemptStore)}
 }
 
+const (
+	verifyPurpose = "verify"
+	resetPurpose  = "reset"
+	unlockPurpose = "unlock"
+)
+
+// compared against when the account doesn't exist, so unknown accounts take as long to reject as wrong passwords
+var dummyPassword, _ = bcrypt.GenerateFromPassword([]byte("not-a-real-password"), bcrypt.DefaultCost)
+
 func (h *Handler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/auth/register", h.handleRegister).Methods("POST")
 	router.HandleFunc("/auth/login", h.handleLogin).Methods("POST")
+	router.HandleFunc("/auth/unlock/{token}", h.handleUnlock).Methods("GET")
+	router.HandleFunc("/auth/2fa/login", h.handleTwoFactorLogin).Methods("POST")
+	router.HandleFunc("/auth/2fa/setup", auth.WithJWTAuth(h.handleTwoFactorSetup, h.store)).Methods("POST")
+	router.HandleFunc("/auth/2fa/confirm", auth.WithJWTAuth(h.handleTwoFactorConfirm, h.store)).Methods("POST")
+	router.HandleFunc("/auth/2fa/disable", auth.WithJWTAuth(h.handleTwoFactorDisable, h.store)).Methods("POST")
 	router.HandleFunc("/auth/updatepassword", auth.WithJWTAuth(h.handleUpdatePassword, h.store)).Methods("PUT") // add jwt auth
+	router.HandleFunc("/auth/verify/request", auth.WithJWTAuth(h.handleRequestVerification, h.store)).Methods("POST")
+	router.HandleFunc("/auth/verify/{token}", h.handleVerify).Methods("GET")
+	router.HandleFunc("/auth/password-reset/request", h.handleRequestPasswordReset).Methods("POST")
+	router.HandleFunc("/auth/password-reset/confirm", h.handleConfirmPasswordReset).Methods("POST")
+	router.HandleFunc("/auth/refresh", h.handleRefresh).Methods("POST")
+	router.HandleFunc("/auth/logout", auth.WithJWTAuth(h.handleLogout, h.store)).Methods("POST")
+	router.HandleFunc("/auth/logout-all", auth.WithJWTAuth(h.handleLogoutAll, h.store)).Methods("POST")
+	router.HandleFunc("/auth/logins", auth.WithJWTAuth(h.handleGetLogins, h.store)).Methods("GET")
+	router.HandleFunc("/neighbors/{neighborId}/role/auth", auth.WithJWTAuth(auth.RequirePermission(h.handlePutRole, h.store, auth.ManageRoles, nil), h.store)).Methods("PUT")
 }
 
 func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
@@ -41,56 +74,85 @@ func (h *Handler) handleRegister(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// need to handle errors better
-	// checkEmail, err := h.store.GetNeighborWithEmail(register.Email)
-	// if checkEmail.Id == 0 {
-	// 	checkUsername, err := h.store.GetNeighborWithUsername(register.Username)
-	// 	if checkUsername.Id == 0 {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(register.Password), bcrypt.DefaultCost)
+	reason, err := Taken(h.store, 0, register.Email, register.Username)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
 		return
 	}
 
-	ip := utils.GetLocalIP()
+	if reason != "" {
+		utils.WriteError(w, http.StatusConflict, fmt.Errorf("%s", reason))
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(register.Password), bcrypt.DefaultCost)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
 
 	err = h.store.CreateNeighbor(types.Neighbors{
 		Email:    register.Email,
 		Username: register.Username,
 		Zipcode:  register.Zipcode,
 		Password: string(hashedPassword),
-		Ip:       ip.String(),
+		Ip:       utils.GetClientIP(r, config.Envs.TrustedProxies),
 	})
+	if utils.IsUniqueViolation(err) {
+		// someone registered the same email or username since the check
+		utils.WriteError(w, http.StatusConflict, fmt.Errorf("email and/or username taken"))
+		return
+	}
+	if utils.IsForeignKeyViolation(err) {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("unknown zipcode"))
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
 
+	neighbor, err := h.store.GetNeighborWithEmailOrUsername(register.Email)
 	if err != nil {
-		utils.WriteError(w, http.StatusAlreadyReported, fmt.Errorf("email and/or username taken"))
+		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
+	}
+
+	// the neighbor can ask for another link if this one doesn't arrive
+	if err := SendVerification(h.tokenStore, h.mailer, neighbor); err != nil {
+		log.Printf("sending verification to neighbor %d: %v", neighbor.Id, err)
+	}
 
-	} else {
-		neighbor, err := h.store.GetNeighborWithEmailOrUsername(register.Email)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(neighbor) // need to return token and ID? Need to run getNeighborById again?
+}
+
+// Taken says whether the email or username belongs to a neighbor other than neighborId, empty strings aren't checked
+func Taken(store types.NeighborStore, neighborId int, email string, username string) (string, error) {
+	if email != "" {
+		neighbor, err := store.GetNeighborWithEmail(email)
 		if err != nil {
-			utils.WriteError(w, http.StatusInternalServerError, err)
-			return
+			return "", err
 		}
 
-		w.WriteHeader(http.StatusCreated)
-		json.NewEncoder(w).Encode(neighbor) // need to return token and ID? Need to run getNeighborById again?
+		if neighbor.Id != 0 && neighbor.Id != neighborId {
+			return "email taken", nil
+		}
 	}
-}
 
-// 		} else {
-// 			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("taken"))
-// 		}
-// 		if err != nil {
-// 			return
-// 		}
-// 	} else {
-// 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("taken"))
-// 	}
-// 	if err != nil {
-// 		return
-// 	}
-// }
+	if username != "" {
+		neighbor, err := store.GetNeighborWithUsername(username)
+		if err != nil {
+			return "", err
+		}
+
+		if neighbor.Id != 0 && neighbor.Id != neighborId {
+			return "username taken", nil
+		}
+	}
+
+	return "", nil
+}
 
 func (h *Handler) handleLogin(w http.ResponseWriter, r *http.Request) {
 	var login types.Login
@@ -105,46 +167,425 @@ func (h *Handler) handleLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ip := utils.GetClientIP(r, config.Envs.TrustedProxies)
+
+	// a missing account looks the same as a wrong password from here on
 	neighbor, err := h.store.GetNeighborWithEmailOrUsername(login.EmailOrUsername)
+	if err != nil {
+		neighbor = &types.Neighbors{Password: string(dummyPassword)}
+	}
+
+	retryAfter, err := h.limiter.RetryAfter(neighbor.Id, ip)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
 		return
 	}
 
-	if bcrypt.CompareHashAndPassword([]byte(neighbor.Password), []byte(login.Password)) != nil {
+	if retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		utils.WriteError(w, http.StatusTooManyRequests, fmt.Errorf("too many failed logins, try again later"))
+		return
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(neighbor.Password), []byte(login.Password)) != nil || neighbor.Id == 0 {
+		lockedOut, err := h.limiter.Fail(neighbor.Id, ip)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+			return
+		}
+
+		if lockedOut {
+			go h.sendUnlock(neighbor)
+		}
+
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("invalid email, username or password"))
+		return
+	}
+
+	twoFactor, err := h.twoFactorStore.GetTwoFactorByNeighborId(neighbor.Id)
+	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
 		return
-	} else {
-		secret := []byte(config.Envs.JWTSecret)
-		token, err := auth.CreateJWT(secret, neighbor.Id)
+	}
+
+	// the session waits for the code, failed logins are only cleared once it's given
+	if twoFactor.Enabled {
+		token, err := auth.CreateTwoFactorToken([]byte(config.Envs.JWTSecret), neighbor)
 		if err != nil {
 			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
 			return
 		}
 
-		json.NewEncoder(w).Encode(map[string]any{
-			"token":          token,
-			"neighborId":     neighbor.Id,
-			"email":          neighbor.Email,
-			"username":       neighbor.Username,
-			"zipcode":        neighbor.Zipcode,
-			"neighborhoodId": neighbor.NeighborhoodId,
-			"verified":       neighbor.Verified,
+		utils.WriteJSON(w, http.StatusOK, map[string]any{
+			"twoFactorRequired": true,
+			"twoFactorToken":    token,
 		})
+		return
+	}
+
+	h.writeLogin(w, r, neighbor)
+}
+
+func (h *Handler) writeLogin(w http.ResponseWriter, r *http.Request, neighbor *types.Neighbors) {
+	if err := h.limiter.Unlock(neighbor.Id); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	// logging in during the grace period keeps the account
+	deletionCancelled, err := h.store.CancelDeletion(neighbor.Id)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	// history is best effort, it shouldn't keep the neighbor from logging in
+	err = h.store.CreateLogin(types.NeighborLogins{
+		NeighborId: neighbor.Id,
+		Ip:         utils.GetClientIP(r, config.Envs.TrustedProxies),
+		UserAgent:  r.UserAgent(),
+	})
+	if err != nil {
+		log.Printf("recording login for neighbor %d: %v", neighbor.Id, err)
+	}
+
+	tokens, err := auth.CreateSession(h.store, neighbor)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]any{
+		"token":             tokens.Token,
+		"refreshToken":      tokens.RefreshToken,
+		"neighborId":        neighbor.Id,
+		"email":             neighbor.Email,
+		"username":          neighbor.Username,
+		"zipcode":           neighbor.Zipcode,
+		"neighborhoodId":    neighbor.NeighborhoodId,
+		"verified":          neighbor.Verified,
+		"deletionCancelled": deletionCancelled,
+	})
+}
+
+// second step of logging in with two factor, wrong codes count as failed logins
+func (h *Handler) handleTwoFactorLogin(w http.ResponseWriter, r *http.Request) {
+	var payload types.TwoFactorLoginPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid submission for %v", errors))
+		return
+	}
+
+	neighborId, tokenVersion, ok := auth.ParseTwoFactorToken(payload.Token)
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("invalid or expired token"))
+		return
+	}
+
+	neighbor, err := h.store.GetNeighborById(neighborId)
+	if err != nil || neighbor.TokenVersion != tokenVersion {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("invalid or expired token"))
+		return
+	}
+
+	ip := utils.GetClientIP(r, config.Envs.TrustedProxies)
+	retryAfter, err := h.limiter.RetryAfter(neighbor.Id, ip)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	if retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		utils.WriteError(w, http.StatusTooManyRequests, fmt.Errorf("too many failed logins, try again later"))
+		return
+	}
+
+	twoFactor, err := h.twoFactorStore.GetTwoFactorByNeighborId(neighbor.Id)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	ok, err = h.checkTwoFactorCode(twoFactor, payload.Code, true)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	if !ok {
+		lockedOut, err := h.limiter.Fail(neighbor.Id, ip)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+			return
+		}
+
+		if lockedOut {
+			go h.sendUnlock(neighbor)
+		}
+
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("invalid code"))
+		return
+	}
+
+	h.writeLogin(w, r, neighbor)
+}
+
+// starts enrollment, two factor isn't required at login until a code from the app is confirmed
+func (h *Handler) handleTwoFactorSetup(w http.ResponseWriter, r *http.Request) {
+	neighborId := auth.GetNeighborIdFromContext(r.Context())
+
+	neighbor, err := h.store.GetNeighborById(neighborId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	twoFactor, err := h.twoFactorStore.GetTwoFactorByNeighborId(neighborId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	if twoFactor.Enabled {
+		utils.WriteError(w, http.StatusConflict, fmt.Errorf("two factor already enabled"))
+		return
+	}
+
+	secret, err := auth.CreateTOTPSecret()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	err = h.twoFactorStore.CreateTwoFactor(types.TwoFactors{
+		NeighborId: neighborId,
+		Secret:     secret,
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]string{
+		"secret": secret,
+		"url":    auth.TOTPURL(secret, neighbor.Email),
+	})
+}
+
+// the recovery codes are only ever shown in this response
+func (h *Handler) handleTwoFactorConfirm(w http.ResponseWriter, r *http.Request) {
+	neighborId := auth.GetNeighborIdFromContext(r.Context())
+
+	var payload types.TwoFactorCodePayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid submission for %v", errors))
+		return
+	}
+
+	twoFactor, err := h.twoFactorStore.GetTwoFactorByNeighborId(neighborId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	if twoFactor.NeighborId == 0 {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("two factor hasn't been set up"))
+		return
+	}
+
+	if twoFactor.Enabled {
+		utils.WriteError(w, http.StatusConflict, fmt.Errorf("two factor already enabled"))
+		return
+	}
+
+	ok, err := h.checkTwoFactorCode(twoFactor, payload.Code, false)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid code"))
+		return
+	}
+
+	codes, hashes, err := auth.CreateRecoveryCodes()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	if err := h.twoFactorStore.EnableTwoFactor(neighborId, hashes); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
 	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string][]string{"recoveryCodes": codes})
 }
 
-// need logic for authenticating user when they forget password so they can update password
+func (h *Handler) handleTwoFactorDisable(w http.ResponseWriter, r *http.Request) {
+	neighborId := auth.GetNeighborIdFromContext(r.Context())
+
+	var payload types.TwoFactorCodePayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid submission for %v", errors))
+		return
+	}
+
+	twoFactor, err := h.twoFactorStore.GetTwoFactorByNeighborId(neighborId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	if !twoFactor.Enabled {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("two factor isn't enabled"))
+		return
+	}
+
+	ok, err := h.checkTwoFactorCode(twoFactor, payload.Code, true)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid code"))
+		return
+	}
+
+	if err := h.twoFactorStore.DeleteTwoFactor(neighborId); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// codes from the app are used up along with every step before them, recovery codes work once each
+func (h *Handler) checkTwoFactorCode(twoFactor *types.TwoFactors, code string, allowRecovery bool) (bool, error) {
+	if twoFactor.NeighborId == 0 {
+		return false, nil
+	}
+
+	if step, ok := auth.ValidateTOTP(twoFactor.Secret, code, time.Now()); ok {
+		return h.twoFactorStore.UseTwoFactorStep(twoFactor.NeighborId, step)
+	}
+
+	if !allowRecovery {
+		return false, nil
+	}
+
+	return h.twoFactorStore.UseRecoveryCode(twoFactor.NeighborId, auth.HashRecoveryCode(code))
+}
+
+// the unlock link clears the account's failed logins so the neighbor doesn't have to wait out the lockout
+func (h *Handler) handleUnlock(w http.ResponseWriter, r *http.Request) {
+	str := mux.Vars(r)["token"]
+	if !auth.VerifySignedToken([]byte(config.Envs.JWTSecret), unlockPurpose, str) {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid or expired token"))
+		return
+	}
+
+	token, err := h.tokenStore.ConsumeNeighborToken(unlockPurpose, auth.HashToken(str))
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	if token.Id == 0 {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid or expired token"))
+		return
+	}
+
+	if err := h.limiter.Unlock(token.NeighborId); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]bool{"unlocked": true})
+}
+
+func (h *Handler) sendUnlock(neighbor *types.Neighbors) {
+	token, tokenHash, err := auth.CreateSignedToken([]byte(config.Envs.JWTSecret), unlockPurpose)
+	if err != nil {
+		log.Printf("creating unlock token for neighbor %d: %v", neighbor.Id, err)
+		return
+	}
+
+	err = h.tokenStore.CreateNeighborToken(types.NeighborTokens{
+		NeighborId: neighbor.Id,
+		Purpose:    unlockPurpose,
+		TokenHash:  tokenHash,
+	}, auth.AccountLockout)
+	if err != nil {
+		log.Printf("saving unlock token for neighbor %d: %v", neighbor.Id, err)
+		return
+	}
+
+	err = h.mailer.Send(mail.Message{
+		To:      neighbor.Email,
+		Subject: "Your Neighborhost account has been locked",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nThere have been too many failed logins to your account, so it's locked for %d hours. If this was you, open this link to unlock it now:\n%s\n\nIf it wasn't you, consider resetting your password once you're back in.\n",
+			neighbor.Username,
+			int(auth.AccountLockout.Hours()),
+			config.Envs.PublicURL+"/api/v1/auth/unlock/"+token,
+		),
+	})
+	if err != nil {
+		log.Printf("sending unlock email to neighbor %d: %v", neighbor.Id, err)
+	}
+}
+
+// neighbors that forgot their password go through the password reset endpoints instead.
+// Every session is logged out, so this device gets a new one.
 func (h *Handler) handleUpdatePassword(w http.ResponseWriter, r *http.Request) {
 	neighborId := auth.GetNeighborIdFromContext(r.Context())
-	var oldPassword types.UpdatePassword
+	var payload types.UpdatePassword
 
-	if err := json.NewDecoder(r.Body).Decode(&oldPassword); err != nil {
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
 		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
 		return
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(oldPassword.Password), bcrypt.DefaultCost)
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid submission for %v", errors))
+		return
+	}
+
+	current, err := h.store.GetNeighborById(neighborId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(current.Password), []byte(payload.CurrentPassword)) != nil {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("incorrect password"))
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(payload.Password), bcrypt.DefaultCost)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
 		return
@@ -158,4 +599,317 @@ func (h *Handler) handleUpdatePassword(w http.ResponseWriter, r *http.Request) {
 		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
 		return
 	}
+
+	neighbor, err := h.store.GetNeighborById(neighborId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	tokens, err := auth.CreateSession(h.store, neighbor)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, tokens)
+}
+
+func (h *Handler) handleRefresh(w http.ResponseWriter, r *http.Request) {
+	var payload types.RefreshPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid submission for %v", errors))
+		return
+	}
+
+	tokens, err := auth.RefreshSession(h.store, payload.RefreshToken)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	if tokens == nil {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("permission denied"))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, tokens)
+}
+
+func (h *Handler) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if err := h.store.RevokeSession(auth.GetSessionIdFromContext(r.Context())); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) handleLogoutAll(w http.ResponseWriter, r *http.Request) {
+	neighborId := auth.GetNeighborIdFromContext(r.Context())
+
+	if err := h.store.RevokeSessionsByNeighborId(neighborId); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) handleRequestVerification(w http.ResponseWriter, r *http.Request) {
+	neighborId := auth.GetNeighborIdFromContext(r.Context())
+
+	neighbor, err := h.store.GetNeighborById(neighborId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	if neighbor.Verified {
+		utils.WriteError(w, http.StatusConflict, fmt.Errorf("already verified"))
+		return
+	}
+
+	if err := SendVerification(h.tokenStore, h.mailer, neighbor); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("could not send verification email"))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusAccepted, map[string]string{"email": neighbor.Email})
+}
+
+// the link is the proof the neighbor can read mail sent to their address
+func (h *Handler) handleVerify(w http.ResponseWriter, r *http.Request) {
+	str := mux.Vars(r)["token"]
+	if !auth.VerifySignedToken([]byte(config.Envs.JWTSecret), verifyPurpose, str) {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid or expired token"))
+		return
+	}
+
+	token, err := h.tokenStore.ConsumeNeighborToken(verifyPurpose, auth.HashToken(str))
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	if token.Id == 0 {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid or expired token"))
+		return
+	}
+
+	err = h.store.UpdateVerifiedWithId(types.Neighbors{
+		Id:       token.NeighborId,
+		Verified: true,
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, map[string]bool{"verified": true})
+}
+
+// SendVerification emails a link to the neighbor's current address, links sent before stop working
+func SendVerification(tokenStore types.TokenStore, mailer mail.Mailer, neighbor *types.Neighbors) error {
+	token, tokenHash, err := auth.CreateSignedToken([]byte(config.Envs.JWTSecret), verifyPurpose)
+	if err != nil {
+		return err
+	}
+
+	expiresIn := time.Duration(config.Envs.VerifyTokenExpirationInSeconds) * time.Second
+	err = tokenStore.CreateNeighborToken(types.NeighborTokens{
+		NeighborId: neighbor.Id,
+		Purpose:    verifyPurpose,
+		TokenHash:  tokenHash,
+	}, expiresIn)
+	if err != nil {
+		return err
+	}
+
+	return mailer.Send(mail.Message{
+		To:      neighbor.Email,
+		Subject: "Verify your Neighborhost email",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nOpen this link to verify your email:\n%s\n\nThe link works once and expires in %d hours. If you didn't sign up for Neighborhost you can ignore this email.\n",
+			neighbor.Username,
+			config.Envs.PublicURL+"/api/v1/auth/verify/"+token,
+			int(expiresIn.Hours()),
+		),
+	})
+}
+
+// answers the same way whether or not an account matches, and looks it up after responding
+// so the response time doesn't give it away either
+func (h *Handler) handleRequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var payload types.PasswordResetRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid submission for %v", errors))
+		return
+	}
+
+	go h.sendPasswordReset(payload.EmailOrUsername)
+
+	utils.WriteJSON(w, http.StatusAccepted, map[string]string{
+		"message": "if an account matches, a reset link has been sent to its email",
+	})
+}
+
+func (h *Handler) handleConfirmPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var payload types.PasswordResetConfirm
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid submission for %v", errors))
+		return
+	}
+
+	if !auth.VerifySignedToken([]byte(config.Envs.JWTSecret), resetPurpose, payload.Token) {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid or expired token"))
+		return
+	}
+
+	token, err := h.tokenStore.ConsumeNeighborToken(resetPurpose, auth.HashToken(payload.Token))
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	if token.Id == 0 {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid or expired token"))
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(payload.Password), bcrypt.DefaultCost)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	err = h.store.UpdatePasswordWithId(types.Neighbors{
+		Id:       token.NeighborId,
+		Password: string(hashedPassword),
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) sendPasswordReset(emailOrUsername string) {
+	neighbor, err := h.store.GetNeighborWithEmailOrUsername(emailOrUsername)
+	if err != nil {
+		return
+	}
+
+	token, tokenHash, err := auth.CreateSignedToken([]byte(config.Envs.JWTSecret), resetPurpose)
+	if err != nil {
+		log.Printf("creating password reset token for neighbor %d: %v", neighbor.Id, err)
+		return
+	}
+
+	expiresIn := time.Duration(config.Envs.ResetTokenExpirationInSeconds) * time.Second
+	err = h.tokenStore.CreateNeighborToken(types.NeighborTokens{
+		NeighborId: neighbor.Id,
+		Purpose:    resetPurpose,
+		TokenHash:  tokenHash,
+	}, expiresIn)
+	if err != nil {
+		log.Printf("saving password reset token for neighbor %d: %v", neighbor.Id, err)
+		return
+	}
+
+	err = h.mailer.Send(mail.Message{
+		To:      neighbor.Email,
+		Subject: "Reset your Neighborhost password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nOpen this link to choose a new password:\n%s\n\nThe link works once and expires in %d minutes. If you didn't ask to reset your password you can ignore this email.\n",
+			neighbor.Username,
+			config.Envs.ClientURL+"/reset-password?token="+url.QueryEscape(token),
+			int(expiresIn.Minutes()),
+		),
+	})
+	if err != nil {
+		log.Printf("sending password reset to neighbor %d: %v", neighbor.Id, err)
+	}
+}
+
+// lets neighbors spot logins that weren't them, newest first
+func (h *Handler) handleGetLogins(w http.ResponseWriter, r *http.Request) {
+	neighborId := auth.GetNeighborIdFromContext(r.Context())
+
+	var cursor types.Cursor
+	limit, err := utils.ReadPagination(r.URL.Query(), &cursor)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	logins, err := h.store.GetLoginsByNeighborId(neighborId, types.Pagination{Limit: limit + 1, After: cursor})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	utils.WritePage(w, http.StatusOK, logins, limit, func(login types.NeighborLogins) any {
+		return types.Cursor{Id: login.Id}
+	})
+}
+
+// admins can't change their own role, so there is always one left to change it back
+func (h *Handler) handlePutRole(w http.ResponseWriter, r *http.Request) {
+	var payload types.RolePayload
+
+	neighborId, err := strconv.Atoi(mux.Vars(r)["neighborId"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return
+	}
+
+	if neighborId == auth.GetNeighborIdFromContext(r.Context()) {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("can't change your own role"))
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid submission for %v", errors))
+		return
+	}
+
+	neighbor, err := h.store.GetNeighborById(neighborId)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("not found"))
+		return
+	}
+
+	neighbor.Role = payload.Role
+	if err := h.store.UpdateRoleWithId(*neighbor); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, neighbor)
 }
@@ -0,0 +1,74 @@
+package notifications
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/jamesdavidyu/neighborhost-service/cmd/model/types"
+	"github.com/jamesdavidyu/neighborhost-service/services/auth"
+	"github.com/jamesdavidyu/neighborhost-service/utils"
+)
+
+type Handler struct {
+	store         types.NotificationStore
+	neighborStore types.NeighborStore
+}
+
+func NewHandler(store types.NotificationStore, neighborStore types.NeighborStore) *Handler {
+	return &Handler{store: store, neighborStore: neighborStore}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/notifications/auth", auth.WithJWTAuth(h.handleGetNotifications, h.neighborStore)).Methods("GET")
+	router.HandleFunc("/notifications/{notificationId}/read/auth", auth.WithJWTAuth(h.handlePutNotificationRead, h.neighborStore)).Methods("PUT")
+}
+
+func (h *Handler) handleGetNotifications(w http.ResponseWriter, r *http.Request) {
+	neighborId := auth.GetNeighborIdFromContext(r.Context())
+
+	var cursor types.Cursor
+	limit, err := utils.ReadPagination(r.URL.Query(), &cursor)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	notifications, err := h.store.GetNotificationsByNeighborId(neighborId, types.Pagination{Limit: limit + 1, After: cursor})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	utils.WritePage(w, http.StatusOK, notifications, limit, func(notification types.Notifications) any {
+		return types.Cursor{Id: notification.Id}
+	})
+}
+
+func (h *Handler) handlePutNotificationRead(w http.ResponseWriter, r *http.Request) {
+	neighborId := auth.GetNeighborIdFromContext(r.Context())
+	str, ok := mux.Vars(r)["notificationId"]
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return
+	}
+
+	notificationId, err := strconv.Atoi(str)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return
+	}
+
+	err = h.store.UpdateNotificationRead(types.Notifications{
+		Id:         notificationId,
+		NeighborId: neighborId,
+		Read:       true,
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
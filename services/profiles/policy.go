@@ -0,0 +1,110 @@
+package profiles
+
+import "github.com/jamesdavidyu/neighborhost-service/cmd/model/types"
+
+const (
+	Private      = "private"
+	Friends      = "friends"
+	Neighborhood = "neighborhood"
+	Public       = "public"
+)
+
+// the wider a field's visibility the higher it ranks, a viewer sees every field ranked at or above their audience
+var visibilityRank = map[string]int{
+	Private:      0,
+	Friends:      1,
+	Neighborhood: 2,
+	Public:       3,
+}
+
+// audience is the narrowest visibility the viewer qualifies for, the owner is the only private audience
+func (h *Handler) audience(profileNeighborId int, viewerId int) (string, error) {
+	if profileNeighborId == viewerId {
+		return Private, nil
+	}
+
+	areFriends, err := h.friendStore.AreFriends(profileNeighborId, viewerId)
+	if err != nil {
+		return "", err
+	}
+
+	if areFriends {
+		return Friends, nil
+	}
+
+	// neighbors without an assigned neighborhood don't share one with anybody
+	ownerNeighborhoodId, err := h.neighborStore.GetNeighborhoodIdById(profileNeighborId)
+	if err != nil {
+		return "", err
+	}
+
+	viewerNeighborhoodId, err := h.neighborStore.GetNeighborhoodIdById(viewerId)
+	if err != nil {
+		return "", err
+	}
+
+	if ownerNeighborhoodId != 0 && ownerNeighborhoodId == viewerNeighborhoodId {
+		return Neighborhood, nil
+	}
+
+	return Public, nil
+}
+
+func visibleTo(visibility string, audience string) bool {
+	rank, ok := visibilityRank[visibility]
+
+	return ok && rank >= visibilityRank[audience]
+}
+
+// ViewProfile blanks out the fields the audience can't see, the bio is always shown
+func ViewProfile(profile *types.Profiles, audience string) *types.Profiles {
+	view := types.Profiles{
+		NeighborId:  profile.NeighborId,
+		Bio:         profile.Bio,
+		Education:   make([]types.Education, 0),
+		Occupations: make([]types.Occupations, 0),
+		Interests:   make([]types.Interests, 0),
+	}
+
+	if visibleTo(profile.DateOfBirthVisibility, audience) {
+		view.DateOfBirth, view.DateOfBirthVisibility = profile.DateOfBirth, profile.DateOfBirthVisibility
+	}
+	if visibleTo(profile.GenderVisibility, audience) {
+		view.Gender, view.GenderVisibility = profile.Gender, profile.GenderVisibility
+	}
+	if visibleTo(profile.RaceVisibility, audience) {
+		view.Race, view.RaceVisibility = profile.Race, profile.RaceVisibility
+	}
+	if visibleTo(profile.EthnicityVisibility, audience) {
+		view.Ethnicity, view.EthnicityVisibility = profile.Ethnicity, profile.EthnicityVisibility
+	}
+	if visibleTo(profile.RelationshipStatusVisibility, audience) {
+		view.RelationshipStatus, view.RelationshipStatusVisibility = profile.RelationshipStatus, profile.RelationshipStatusVisibility
+	}
+	if visibleTo(profile.ReligionVisibility, audience) {
+		view.Religion, view.ReligionVisibility = profile.Religion, profile.ReligionVisibility
+	}
+	if visibleTo(profile.PoliticsVisibility, audience) {
+		view.Politics, view.PoliticsVisibility = profile.Politics, profile.PoliticsVisibility
+	}
+
+	for _, education := range profile.Education {
+		if visibleTo(education.Visibility, audience) {
+			view.Education = append(view.Education, education)
+		}
+	}
+
+	for _, occupation := range profile.Occupations {
+		if visibleTo(occupation.Visibility, audience) {
+			view.Occupations = append(view.Occupations, occupation)
+		}
+	}
+
+	for _, interest := range profile.Interests {
+		if visibleTo(interest.Visibility, audience) {
+			view.Interests = append(view.Interests, interest)
+		}
+	}
+
+	return &view
+}
@@ -1 +1,562 @@
 package profiles
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/jamesdavidyu/neighborhost-service/cmd/model/types"
+	"github.com/jamesdavidyu/neighborhost-service/services/auth"
+	"github.com/jamesdavidyu/neighborhost-service/services/images"
+	"github.com/jamesdavidyu/neighborhost-service/utils"
+)
+
+type Handler struct {
+	store         types.ProfileStore
+	neighborStore types.NeighborStore
+	friendStore   types.FriendStore
+	imageStore    types.ImageStore
+	blobStore     types.BlobStore
+}
+
+func NewHandler(store types.ProfileStore, neighborStore types.NeighborStore, friendStore types.FriendStore, imageStore types.ImageStore, blobStore types.BlobStore) *Handler {
+	return &Handler{store: store, neighborStore: neighborStore, friendStore: friendStore, imageStore: imageStore, blobStore: blobStore}
+}
+
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/profiles/{neighborId:[0-9]+}", auth.WithJWTAuth(h.handleGetProfile, h.neighborStore)).Methods("GET")
+	router.HandleFunc("/profiles/{neighborId:[0-9]+}", auth.WithJWTAuth(h.handlePutProfile, h.neighborStore)).Methods("PUT")
+	router.HandleFunc("/profiles/{neighborId:[0-9]+}/avatar", auth.WithJWTAuth(h.handleGetAvatar, h.neighborStore)).Methods("GET")
+	router.HandleFunc("/profiles/me/avatar/auth", auth.WithJWTAuth(h.handlePutAvatar, h.neighborStore)).Methods("PUT")
+	router.HandleFunc("/profiles/me/avatar/auth", auth.WithJWTAuth(h.handleDeleteAvatar, h.neighborStore)).Methods("DELETE")
+	router.HandleFunc("/profiles/me/history/auth", auth.WithJWTAuth(h.handleGetProfileHistory, h.neighborStore)).Methods("GET")
+	router.HandleFunc("/profiles/me/education/auth", auth.WithJWTAuth(h.handleCreateEducation, h.neighborStore)).Methods("POST")
+	router.HandleFunc("/profiles/me/education/{educationId:[0-9]+}/auth", auth.WithJWTAuth(h.handleUpdateEducation, h.neighborStore)).Methods("PUT")
+	router.HandleFunc("/profiles/me/education/{educationId:[0-9]+}/auth", auth.WithJWTAuth(h.handleDeleteEducation, h.neighborStore)).Methods("DELETE")
+	router.HandleFunc("/profiles/me/occupations/auth", auth.WithJWTAuth(h.handleCreateOccupation, h.neighborStore)).Methods("POST")
+	router.HandleFunc("/profiles/me/occupations/{occupationId:[0-9]+}/auth", auth.WithJWTAuth(h.handleUpdateOccupation, h.neighborStore)).Methods("PUT")
+	router.HandleFunc("/profiles/me/occupations/{occupationId:[0-9]+}/auth", auth.WithJWTAuth(h.handleDeleteOccupation, h.neighborStore)).Methods("DELETE")
+	router.HandleFunc("/profiles/me/interests/auth", auth.WithJWTAuth(h.handleCreateInterest, h.neighborStore)).Methods("POST")
+	router.HandleFunc("/profiles/me/interests/{interestId:[0-9]+}/auth", auth.WithJWTAuth(h.handleDeleteInterest, h.neighborStore)).Methods("DELETE")
+	router.HandleFunc("/interests", auth.WithJWTAuth(h.handleGetInterestTaxonomy, h.neighborStore)).Methods("GET")
+	router.HandleFunc("/interests/auth", auth.WithJWTAuth(auth.RequirePermission(h.handleCreateInterestTaxonomy, h.neighborStore, auth.ManageInterests, nil), h.neighborStore)).Methods("POST")
+}
+
+// the owner sees everything, everyone else gets the fields whose visibility reaches them
+func (h *Handler) handleGetProfile(w http.ResponseWriter, r *http.Request) {
+	neighborId, err := strconv.Atoi(mux.Vars(r)["neighborId"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return
+	}
+
+	viewerId := auth.GetNeighborIdFromContext(r.Context())
+	if !h.checkNotBlocked(w, neighborId, viewerId) {
+		return
+	}
+
+	profile, err := h.store.GetProfileByNeighborId(neighborId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	if profile.NeighborId == 0 {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("not found"))
+		return
+	}
+
+	audience, err := h.audience(neighborId, viewerId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, ViewProfile(profile, audience))
+}
+
+func (h *Handler) handlePutProfile(w http.ResponseWriter, r *http.Request) {
+	neighborId, err := strconv.Atoi(mux.Vars(r)["neighborId"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return
+	}
+
+	if neighborId != auth.GetNeighborIdFromContext(r.Context()) {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("permission denied"))
+		return
+	}
+
+	var payload types.ProfilePayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid submission for %v", errors))
+		return
+	}
+
+	var dateOfBirth time.Time
+	if payload.DateOfBirth != nil {
+		dateOfBirth, err = time.Parse(time.DateOnly, payload.DateOfBirth.Value)
+		if err != nil || dateOfBirth.After(time.Now()) {
+			utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid date of birth"))
+			return
+		}
+	}
+
+	if err := h.updateProfile(neighborId, payload, dateOfBirth); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	profile, err := h.store.GetProfileByNeighborId(neighborId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, profile)
+}
+
+func (h *Handler) updateProfile(neighborId int, payload types.ProfilePayload, dateOfBirth time.Time) error {
+	if payload.Bio != nil {
+		if err := h.store.CreateBio(types.Bios{NeighborId: neighborId, Bio: *payload.Bio}); err != nil {
+			return err
+		}
+	}
+
+	if field := payload.DateOfBirth; field != nil {
+		if err := h.store.CreateDateOfBirth(types.DatesOfBirth{NeighborId: neighborId, DateOfBirth: dateOfBirth, Visibility: visibility(field.Visibility)}); err != nil {
+			return err
+		}
+	}
+
+	if field := payload.Gender; field != nil {
+		if err := h.store.CreateGender(types.Genders{NeighborId: neighborId, Gender: field.Value, Visibility: visibility(field.Visibility)}); err != nil {
+			return err
+		}
+	}
+
+	if field := payload.Race; field != nil {
+		if err := h.store.CreateRace(types.Races{NeighborId: neighborId, Race: field.Value, Visibility: visibility(field.Visibility)}); err != nil {
+			return err
+		}
+	}
+
+	if field := payload.Ethnicity; field != nil {
+		if err := h.store.CreateEthnicity(types.Ethnicities{NeighborId: neighborId, Ethnicity: field.Value, Visibility: visibility(field.Visibility)}); err != nil {
+			return err
+		}
+	}
+
+	if field := payload.RelationshipStatus; field != nil {
+		if err := h.store.CreateRelationshipStatus(types.RelationshipStatuses{NeighborId: neighborId, RelationshipStatus: field.Value, Visibility: visibility(field.Visibility)}); err != nil {
+			return err
+		}
+	}
+
+	if field := payload.Religion; field != nil {
+		if err := h.store.CreateReligion(types.Religions{NeighborId: neighborId, Religion: field.Value, Visibility: visibility(field.Visibility)}); err != nil {
+			return err
+		}
+	}
+
+	if field := payload.Politics; field != nil {
+		if err := h.store.CreatePolitics(types.Politics{NeighborId: neighborId, Politics: field.Value, Visibility: visibility(field.Visibility)}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (h *Handler) handleGetAvatar(w http.ResponseWriter, r *http.Request) {
+	neighborId, err := strconv.Atoi(mux.Vars(r)["neighborId"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return
+	}
+
+	if !h.checkNotBlocked(w, neighborId, auth.GetNeighborIdFromContext(r.Context())) {
+		return
+	}
+
+	avatar, err := h.imageStore.GetAvatarByNeighborId(neighborId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	if avatar.Id == 0 {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("not found"))
+		return
+	}
+
+	images.Serve(w, r, h.blobStore, avatar)
+}
+
+// multipart with the picture in the image field, the avatar it replaces is swept later
+func (h *Handler) handlePutAvatar(w http.ResponseWriter, r *http.Request) {
+	neighborId := auth.GetNeighborIdFromContext(r.Context())
+
+	image, ok := images.Upload(w, r, h.blobStore, fmt.Sprintf("avatars/%d", neighborId), images.AvatarSize, images.AvatarThumbnailSize)
+	if !ok {
+		return
+	}
+
+	if err := h.imageStore.CreateAvatar(neighborId, *image); err != nil {
+		images.Discard(h.blobStore, image)
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	avatar, err := h.imageStore.GetAvatarByNeighborId(neighborId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, avatar)
+}
+
+func (h *Handler) handleDeleteAvatar(w http.ResponseWriter, r *http.Request) {
+	deleted, err := h.imageStore.DeleteAvatar(auth.GetNeighborIdFromContext(r.Context()))
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	if !deleted {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("not found"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// only the owner sees the history, it includes values that were never visible to anyone else
+func (h *Handler) handleGetProfileHistory(w http.ResponseWriter, r *http.Request) {
+	history, err := h.store.GetProfileHistoryByNeighborId(auth.GetNeighborIdFromContext(r.Context()))
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, history)
+}
+
+func (h *Handler) handleCreateEducation(w http.ResponseWriter, r *http.Request) {
+	education, ok := readEducation(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.store.CreateEducation(*education); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (h *Handler) handleUpdateEducation(w http.ResponseWriter, r *http.Request) {
+	educationId, err := strconv.Atoi(mux.Vars(r)["educationId"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return
+	}
+
+	education, ok := readEducation(w, r)
+	if !ok {
+		return
+	}
+	education.Id = educationId
+
+	updated, err := h.store.UpdateEducation(*education)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	if !updated {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("not found"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) handleDeleteEducation(w http.ResponseWriter, r *http.Request) {
+	educationId, err := strconv.Atoi(mux.Vars(r)["educationId"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return
+	}
+
+	deleted, err := h.store.DeleteEducation(educationId, auth.GetNeighborIdFromContext(r.Context()))
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	if !deleted {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("not found"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) handleCreateOccupation(w http.ResponseWriter, r *http.Request) {
+	occupation, ok := readOccupation(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.store.CreateOccupation(*occupation); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (h *Handler) handleUpdateOccupation(w http.ResponseWriter, r *http.Request) {
+	occupationId, err := strconv.Atoi(mux.Vars(r)["occupationId"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return
+	}
+
+	occupation, ok := readOccupation(w, r)
+	if !ok {
+		return
+	}
+	occupation.Id = occupationId
+
+	updated, err := h.store.UpdateOccupation(*occupation)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	if !updated {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("not found"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) handleDeleteOccupation(w http.ResponseWriter, r *http.Request) {
+	occupationId, err := strconv.Atoi(mux.Vars(r)["occupationId"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return
+	}
+
+	deleted, err := h.store.DeleteOccupation(occupationId, auth.GetNeighborIdFromContext(r.Context()))
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	if !deleted {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("not found"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) handleCreateInterest(w http.ResponseWriter, r *http.Request) {
+	var payload types.InterestPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid submission for %v", errors))
+		return
+	}
+
+	err := h.store.CreateInterest(types.Interests{
+		NeighborId: auth.GetNeighborIdFromContext(r.Context()),
+		InterestId: payload.InterestId,
+		Visibility: visibility(payload.Visibility),
+	})
+	if utils.IsForeignKeyViolation(err) {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("unknown interest"))
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (h *Handler) handleDeleteInterest(w http.ResponseWriter, r *http.Request) {
+	interestId, err := strconv.Atoi(mux.Vars(r)["interestId"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return
+	}
+
+	deleted, err := h.store.DeleteInterest(auth.GetNeighborIdFromContext(r.Context()), interestId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	if !deleted {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("not found"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) handleGetInterestTaxonomy(w http.ResponseWriter, r *http.Request) {
+	taxonomy, err := h.store.GetInterestTaxonomy()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, taxonomy)
+}
+
+func (h *Handler) handleCreateInterestTaxonomy(w http.ResponseWriter, r *http.Request) {
+	var payload types.InterestTaxonomyPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid submission for %v", errors))
+		return
+	}
+
+	err := h.store.CreateInterestTaxonomy(types.InterestTaxonomy{Interest: payload.Interest, Category: payload.Category})
+	if utils.IsUniqueViolation(err) {
+		utils.WriteError(w, http.StatusConflict, fmt.Errorf("interest already exists"))
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+// a neighbor who blocked the viewer looks like they aren't there, the response is written when it returns false
+func (h *Handler) checkNotBlocked(w http.ResponseWriter, neighborId int, viewerId int) bool {
+	blocked, err := h.friendStore.IsBlocked(neighborId, viewerId)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("database error"))
+		return false
+	}
+
+	if blocked {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("not found"))
+		return false
+	}
+
+	return true
+}
+
+// fields sent without a visibility stay private
+func visibility(visibility string) string {
+	if visibility == "" {
+		return Private
+	}
+
+	return visibility
+}
+
+func readEducation(w http.ResponseWriter, r *http.Request) (*types.Education, bool) {
+	var payload types.EducationPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return nil, false
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid submission for %v", errors))
+		return nil, false
+	}
+
+	start, end, ok := parseSpan(w, payload.Start, payload.End)
+	if !ok {
+		return nil, false
+	}
+
+	return &types.Education{
+		NeighborId: auth.GetNeighborIdFromContext(r.Context()),
+		School:     payload.School,
+		Degree:     payload.Degree,
+		Start:      start,
+		End:        end,
+		Visibility: visibility(payload.Visibility),
+	}, true
+}
+
+func readOccupation(w http.ResponseWriter, r *http.Request) (*types.Occupations, bool) {
+	var payload types.OccupationPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("bad data"))
+		return nil, false
+	}
+
+	if err := utils.Validate.Struct(payload); err != nil {
+		errors := err.(validator.ValidationErrors)
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid submission for %v", errors))
+		return nil, false
+	}
+
+	start, end, ok := parseSpan(w, payload.Start, payload.End)
+	if !ok {
+		return nil, false
+	}
+
+	return &types.Occupations{
+		NeighborId: auth.GetNeighborIdFromContext(r.Context()),
+		Role:       payload.Role,
+		Employer:   payload.Employer,
+		Start:      start,
+		End:        end,
+		Visibility: visibility(payload.Visibility),
+	}, true
+}
+
+// the payload's dates are already validated, this only checks they're in order
+func parseSpan(w http.ResponseWriter, startDate string, endDate string) (time.Time, *time.Time, bool) {
+	start, _ := time.Parse(time.DateOnly, startDate)
+	if endDate == "" {
+		return start, nil, true
+	}
+
+	end, _ := time.Parse(time.DateOnly, endDate)
+	if end.Before(start) {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("end is before start"))
+		return time.Time{}, nil, false
+	}
+
+	return start, &end, true
+}
@@ -0,0 +1,221 @@
+package recurrence
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rule is the subset of an RFC 5545 RRULE that events support, e.g.
+// FREQ=WEEKLY;INTERVAL=2;UNTIL=20241231;EXDATE=20240704,20240718
+// UNTIL and EXDATE are read as wall clock times in the event's own timezone.
+type Rule struct {
+	Freq       string
+	Interval   int
+	Count      int
+	Until      time.Time
+	Exceptions []time.Time
+}
+
+const (
+	Daily   = "DAILY"
+	Weekly  = "WEEKLY"
+	Monthly = "MONTHLY"
+)
+
+const (
+	dateLayout     = "20060102"
+	dateTimeLayout = "20060102T150405"
+)
+
+// Parse returns a nil rule for events that don't repeat
+func Parse(value string) (*Rule, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.EqualFold(value, "none") {
+		return nil, nil
+	}
+
+	rule := &Rule{Interval: 1}
+	for _, part := range strings.Split(strings.TrimPrefix(value, "RRULE:"), ";") {
+		key, val, ok := strings.Cut(part, "=")
+		if !ok || val == "" {
+			return nil, fmt.Errorf("invalid recurrence part %q", part)
+		}
+
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			freq := strings.ToUpper(val)
+			if freq != Daily && freq != Weekly && freq != Monthly {
+				return nil, fmt.Errorf("unsupported recurrence frequency %q", val)
+			}
+			rule.Freq = freq
+		case "INTERVAL":
+			interval, err := strconv.Atoi(val)
+			if err != nil || interval < 1 {
+				return nil, fmt.Errorf("invalid recurrence interval %q", val)
+			}
+			rule.Interval = interval
+		case "COUNT":
+			count, err := strconv.Atoi(val)
+			if err != nil || count < 1 {
+				return nil, fmt.Errorf("invalid recurrence count %q", val)
+			}
+			rule.Count = count
+		case "UNTIL":
+			until, err := parseUntil(val)
+			if err != nil {
+				return nil, err
+			}
+			rule.Until = until
+		case "EXDATE":
+			for _, date := range strings.Split(val, ",") {
+				exception, err := time.Parse(dateLayout, date)
+				if err != nil {
+					return nil, fmt.Errorf("invalid recurrence exception %q", date)
+				}
+				rule.Exceptions = append(rule.Exceptions, exception)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported recurrence part %q", key)
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, fmt.Errorf("recurrence is missing FREQ")
+	}
+
+	if rule.Count > 0 && !rule.Until.IsZero() {
+		return nil, fmt.Errorf("recurrence can't have both COUNT and UNTIL")
+	}
+
+	return rule, nil
+}
+
+// a date-only UNTIL includes the whole day
+func parseUntil(value string) (time.Time, error) {
+	if until, err := time.Parse(dateLayout, value); err == nil {
+		return until.Add(24*time.Hour - time.Nanosecond), nil
+	}
+
+	until, err := time.Parse(dateTimeLayout, strings.TrimSuffix(value, "Z"))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid recurrence until %q", value)
+	}
+
+	return until, nil
+}
+
+// String is the canonical form stored in events.reoccurrence
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.Format(dateTimeLayout))
+	}
+
+	if len(r.Exceptions) > 0 {
+		dates := make([]string, len(r.Exceptions))
+		for i, exception := range r.Exceptions {
+			dates[i] = exception.Format(dateLayout)
+		}
+		parts = append(parts, "EXDATE="+strings.Join(dates, ","))
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// RRULE is the rule as an iCalendar property value, UNTIL has to be in UTC there
+func (r *Rule) RRULE(loc *time.Location) string {
+	rule := *r
+	rule.Exceptions = nil
+
+	value := rule.String()
+	if !r.Until.IsZero() {
+		until := time.Date(r.Until.Year(), r.Until.Month(), r.Until.Day(), r.Until.Hour(), r.Until.Minute(), r.Until.Second(), 0, loc)
+		value = strings.Replace(value, "UNTIL="+r.Until.Format(dateTimeLayout), "UNTIL="+until.UTC().Format(dateTimeLayout)+"Z", 1)
+	}
+
+	return value
+}
+
+// ExceptionStarts are the excluded dates at start's time of day, the form iCalendar EXDATEs take
+func (r *Rule) ExceptionStarts(start time.Time) []time.Time {
+	starts := make([]time.Time, len(r.Exceptions))
+	for i, exception := range r.Exceptions {
+		starts[i] = time.Date(exception.Year(), exception.Month(), exception.Day(), start.Hour(), start.Minute(), start.Second(), 0, start.Location())
+	}
+
+	return starts
+}
+
+// Between returns the starts of every occurrence in [from, to), first one included.
+// Dates are advanced on the calendar of start's location so wall clock times survive DST changes.
+func (r *Rule) Between(start time.Time, from time.Time, to time.Time) []time.Time {
+	occurrences := make([]time.Time, 0)
+
+	// without COUNT the occurrences before the window don't matter, so daily and weekly rules can skip ahead
+	n := 0
+	if r.Count == 0 && r.Freq != Monthly && from.After(start) {
+		period := r.Interval * 24
+		if r.Freq == Weekly {
+			period *= 7
+		}
+		n = int(from.Sub(start).Hours()) / period
+		if n > 0 {
+			n--
+		}
+	}
+
+	for generated := 0; ; n++ {
+		occurrence, ok := r.nth(start, n)
+		if !ok {
+			continue
+		}
+		generated++
+
+		if !occurrence.Before(to) || (!r.Until.IsZero() && occurrence.After(r.Until)) {
+			break
+		}
+
+		if !occurrence.Before(from) && !r.isException(occurrence) {
+			occurrences = append(occurrences, occurrence)
+		}
+
+		if r.Count > 0 && generated >= r.Count {
+			break
+		}
+	}
+
+	return occurrences
+}
+
+// months without start's day of the month are skipped like RFC 5545 does
+func (r *Rule) nth(start time.Time, n int) (time.Time, bool) {
+	switch r.Freq {
+	case Daily:
+		return start.AddDate(0, 0, n*r.Interval), true
+	case Weekly:
+		return start.AddDate(0, 0, 7*n*r.Interval), true
+	default:
+		occurrence := start.AddDate(0, n*r.Interval, 0)
+		return occurrence, occurrence.Day() == start.Day()
+	}
+}
+
+func (r *Rule) isException(occurrence time.Time) bool {
+	for _, exception := range r.Exceptions {
+		if occurrence.Year() == exception.Year() && occurrence.YearDay() == exception.YearDay() {
+			return true
+		}
+	}
+
+	return false
+}
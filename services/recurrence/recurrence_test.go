@@ -0,0 +1,241 @@
+package recurrence
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// event starts come out of postgres as wall clock times in UTC
+func at(year int, month time.Month, day int, hour int, minute int) time.Time {
+	return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"empty", "", ""},
+		{"none", "None", ""},
+		{"prefix and lower case", "RRULE:freq=weekly;interval=2", "FREQ=WEEKLY;INTERVAL=2"},
+		{"interval of one is dropped", "FREQ=DAILY;INTERVAL=1;COUNT=3", "FREQ=DAILY;COUNT=3"},
+		{"date until covers the day", "FREQ=MONTHLY;UNTIL=20241231", "FREQ=MONTHLY;UNTIL=20241231T235959"},
+		{"utc until", "FREQ=DAILY;UNTIL=20241231T120000Z", "FREQ=DAILY;UNTIL=20241231T120000"},
+		{"exceptions", "FREQ=WEEKLY;EXDATE=20240704,20240718", "FREQ=WEEKLY;EXDATE=20240704,20240718"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rule, err := Parse(test.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := ""
+			if rule != nil {
+				got = rule.String()
+			}
+
+			if got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"count and until", "FREQ=DAILY;COUNT=3;UNTIL=20240110"},
+		{"unknown part", "FREQ=WEEKLY;BYDAY=MO"},
+		{"zero interval", "FREQ=DAILY;INTERVAL=0"},
+		{"negative interval", "FREQ=DAILY;INTERVAL=-1"},
+		{"zero count", "FREQ=DAILY;COUNT=0"},
+		{"unsupported frequency", "FREQ=YEARLY"},
+		{"missing frequency", "INTERVAL=2"},
+		{"part without a value", "FREQ=DAILY;COUNT"},
+		{"empty value", "FREQ="},
+		{"bad until", "FREQ=DAILY;UNTIL=tomorrow"},
+		{"bad exception", "FREQ=DAILY;EXDATE=2024-07-04"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if rule, err := Parse(test.value); err == nil {
+				t.Errorf("got %v, want an error", rule)
+			}
+		})
+	}
+}
+
+func TestBetween(t *testing.T) {
+	monday := at(2024, time.January, 1, 10, 0)
+	endOfJanuary := at(2024, time.January, 31, 10, 0)
+
+	tests := []struct {
+		name  string
+		rule  string
+		start time.Time
+		from  time.Time
+		to    time.Time
+		want  []time.Time
+	}{
+		{
+			"daily skips ahead to the window",
+			"FREQ=DAILY;INTERVAL=3", monday, at(2024, time.March, 1, 0, 0), at(2024, time.March, 8, 0, 0),
+			[]time.Time{at(2024, time.March, 1, 10, 0), at(2024, time.March, 4, 10, 0), at(2024, time.March, 7, 10, 0)},
+		},
+		{
+			"daily window starting on an occurrence",
+			"FREQ=DAILY;INTERVAL=3", monday, at(2024, time.March, 1, 10, 0), at(2024, time.March, 5, 0, 0),
+			[]time.Time{at(2024, time.March, 1, 10, 0), at(2024, time.March, 4, 10, 0)},
+		},
+		{
+			"daily window starting just after an occurrence",
+			"FREQ=DAILY;INTERVAL=3", monday, at(2024, time.March, 1, 10, 1), at(2024, time.March, 5, 0, 0),
+			[]time.Time{at(2024, time.March, 4, 10, 0)},
+		},
+		{
+			"weekly skips ahead to the window",
+			"FREQ=WEEKLY;INTERVAL=2", monday, at(2024, time.June, 1, 0, 0), at(2024, time.July, 1, 0, 0),
+			[]time.Time{at(2024, time.June, 3, 10, 0), at(2024, time.June, 17, 10, 0)},
+		},
+		{
+			"window ends before the occurrence on its last day",
+			"FREQ=WEEKLY", monday, at(2024, time.January, 8, 0, 0), at(2024, time.January, 15, 10, 0),
+			[]time.Time{at(2024, time.January, 8, 10, 0)},
+		},
+		{
+			"window before the start",
+			"FREQ=DAILY", monday, at(2023, time.December, 1, 0, 0), at(2024, time.January, 1, 0, 0),
+			[]time.Time{},
+		},
+		{
+			"monthly from the 31st skips short months",
+			"FREQ=MONTHLY", endOfJanuary, endOfJanuary, at(2024, time.August, 1, 0, 0),
+			[]time.Time{endOfJanuary, at(2024, time.March, 31, 10, 0), at(2024, time.May, 31, 10, 0), at(2024, time.July, 31, 10, 0)},
+		},
+		{
+			"count leaves out skipped months",
+			"FREQ=MONTHLY;COUNT=3", endOfJanuary, endOfJanuary, at(2025, time.January, 1, 0, 0),
+			[]time.Time{endOfJanuary, at(2024, time.March, 31, 10, 0), at(2024, time.May, 31, 10, 0)},
+		},
+		{
+			"count includes occurrences before the window",
+			"FREQ=DAILY;COUNT=5", monday, at(2024, time.January, 3, 0, 0), at(2024, time.February, 1, 0, 0),
+			[]time.Time{at(2024, time.January, 3, 10, 0), at(2024, time.January, 4, 10, 0), at(2024, time.January, 5, 10, 0)},
+		},
+		{
+			"count includes exceptions",
+			"FREQ=DAILY;COUNT=3;EXDATE=20240102", monday, monday, at(2024, time.February, 1, 0, 0),
+			[]time.Time{monday, at(2024, time.January, 3, 10, 0)},
+		},
+		{
+			"date until includes the whole day",
+			"FREQ=DAILY;UNTIL=20240103", at(2024, time.January, 1, 23, 30), monday, at(2024, time.February, 1, 0, 0),
+			[]time.Time{at(2024, time.January, 1, 23, 30), at(2024, time.January, 2, 23, 30), at(2024, time.January, 3, 23, 30)},
+		},
+		{
+			"until at an occurrence includes it",
+			"FREQ=DAILY;UNTIL=20240102T100000", monday, monday, at(2024, time.February, 1, 0, 0),
+			[]time.Time{monday, at(2024, time.January, 2, 10, 0)},
+		},
+		{
+			"until before an occurrence stops at the one before",
+			"FREQ=DAILY;UNTIL=20240102T095959", monday, monday, at(2024, time.February, 1, 0, 0),
+			[]time.Time{monday},
+		},
+		{
+			"exceptions are removed",
+			"FREQ=WEEKLY;EXDATE=20240108,20240122", monday, monday, at(2024, time.February, 1, 0, 0),
+			[]time.Time{monday, at(2024, time.January, 15, 10, 0), at(2024, time.January, 29, 10, 0)},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rule, err := Parse(test.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := rule.Between(test.start, test.from, test.to); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestBetweenKeepsWallClockAcrossDST(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	rule, err := Parse("FREQ=WEEKLY")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2024, time.March, 3, 9, 0, 0, 0, newYork)
+	got := rule.Between(start, start, time.Date(2024, time.March, 17, 0, 0, 0, 0, newYork))
+	for _, occurrence := range got {
+		if occurrence.Hour() != 9 {
+			t.Errorf("got %v, want 9am", occurrence)
+		}
+	}
+
+	if len(got) != 2 {
+		t.Errorf("got %d occurrences, want 2", len(got))
+	}
+}
+
+func TestRRULE(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	tests := []struct {
+		name  string
+		value string
+		loc   *time.Location
+		want  string
+	}{
+		{"summer until", "FREQ=WEEKLY;UNTIL=20240630T180000", newYork, "FREQ=WEEKLY;UNTIL=20240630T220000Z"},
+		{"winter date until", "FREQ=DAILY;UNTIL=20240115", newYork, "FREQ=DAILY;UNTIL=20240116T045959Z"},
+		{"utc", "FREQ=DAILY;UNTIL=20240115T120000", time.UTC, "FREQ=DAILY;UNTIL=20240115T120000Z"},
+		{"exceptions are left to EXDATE", "FREQ=WEEKLY;INTERVAL=2;EXDATE=20240704", newYork, "FREQ=WEEKLY;INTERVAL=2"},
+		{"count", "FREQ=MONTHLY;COUNT=6", newYork, "FREQ=MONTHLY;COUNT=6"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rule, err := Parse(test.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := rule.RRULE(test.loc); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestExceptionStarts(t *testing.T) {
+	rule, err := Parse("FREQ=WEEKLY;EXDATE=20240704,20240718")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := rule.ExceptionStarts(at(2024, time.June, 27, 18, 30))
+	want := []time.Time{at(2024, time.July, 4, 18, 30), at(2024, time.July, 18, 18, 30)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -6,16 +6,23 @@
 5. FOR NEIGHBORHOODS CONTROLLERS
 6. FOR EVENT CONTROLLERS
 7. FOR FRIENDS CONTROLLERS
+8. FOR CALENDAR CONTROLLERS
+9. FOR NOTIFICATIONS CONTROLLERS
+10. FOR TOKENS CONTROLLERS
+11. FOR TWO FACTOR CONTROLLERS
+12. FOR IMAGES CONTROLLERS
 */
 
 package utils
 
 import (
 	"database/sql"
+	"encoding/base64"
 	"encoding/json"
-	"log"
-	"net"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/netip"
 	"net/url"
 	"strconv"
 	"strings"
@@ -33,7 +40,7 @@ var Validate = validator.New()
 func EnableCORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
 
 		if r.Method == "OPTIONS" {
@@ -63,6 +70,24 @@ func WriteError(w http.ResponseWriter, status int, err error) {
 	WriteJSON(w, status, map[string]string{"error": err.Error()})
 }
 
+// postgres errors are matched by SQLSTATE so the driver's error type isn't needed here
+func sqlState(err error) string {
+	var pgErr interface{ SQLState() string }
+	if errors.As(err, &pgErr) {
+		return pgErr.SQLState()
+	}
+
+	return ""
+}
+
+func IsUniqueViolation(err error) bool {
+	return sqlState(err) == "23505"
+}
+
+func IsForeignKeyViolation(err error) bool {
+	return sqlState(err) == "23503"
+}
+
 func ReadString(qs url.Values, key string, defaultValue string) string {
 	value := qs.Get(key)
 	if value == "" {
@@ -124,6 +149,79 @@ func ToProperCase(input string) string {
 	return strings.Join(words, " ")
 }
 
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// cursors are base64 encoded json so clients treat them as opaque
+func EncodeCursor(cursor any) string {
+	value, err := json.Marshal(cursor)
+	if err != nil {
+		return ""
+	}
+
+	return base64.RawURLEncoding.EncodeToString(value)
+}
+
+func DecodeCursor(value string, cursor any) error {
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return fmt.Errorf("invalid cursor")
+	}
+
+	if err := json.Unmarshal(decoded, cursor); err != nil {
+		return fmt.Errorf("invalid cursor")
+	}
+
+	return nil
+}
+
+// reads ?limit= and ?cursor=, cursor is left as is on the first page
+func ReadPagination(qs url.Values, cursor any) (int, error) {
+	limit := ReadInt(qs, "limit", DefaultPageLimit)
+	if limit < 1 {
+		limit = DefaultPageLimit
+	}
+
+	if limit > MaxPageLimit {
+		limit = MaxPageLimit
+	}
+
+	if value := qs.Get("cursor"); value != "" {
+		if err := DecodeCursor(value, cursor); err != nil {
+			return 0, err
+		}
+	}
+
+	return limit, nil
+}
+
+// stores are asked for limit+1 rows, an extra row means there's another page after this one
+func WritePage[T any](w http.ResponseWriter, status int, items []T, limit int, cursor func(T) any) error {
+	return WriteFilteredPage(w, status, items, limit, cursor, nil)
+}
+
+// WriteFilteredPage filters the page after it's cut, the cursor still comes from the rows the store returned
+// so a page that filter shortens keeps pointing at the next one
+func WriteFilteredPage[T any](w http.ResponseWriter, status int, items []T, limit int, cursor func(T) any, filter func([]T) []T) error {
+	page := struct {
+		Items      []T    `json:"items"`
+		NextCursor string `json:"nextCursor"`
+	}{Items: items}
+
+	if len(items) > limit {
+		page.Items = items[:limit]
+		page.NextCursor = EncodeCursor(cursor(items[limit-1]))
+	}
+
+	if filter != nil {
+		page.Items = filter(page.Items)
+	}
+
+	return WriteJSON(w, status, page)
+}
+
 /* 2. FOR ZIPCODES CONTROLLERS */
 
 func ScanRowIntoZipcodes(rows *sql.Rows) (*types.Zipcodes, error) {
@@ -144,6 +242,9 @@ func ScanRowIntoZipcodes(rows *sql.Rows) (*types.Zipcodes, error) {
 
 /* 3. FOR NEIGHBORS CONTROLLERS/SERVICES */
 
+// addresses get neighborhood 1 until neighborhoods are assigned, so it doesn't count as sharing one
+const UnassignedNeighborhoodId = 1
+
 func ScanRowIntoNeighbor(rows *sql.Rows) (*types.Neighbors, error) {
 	neighbor := new(types.Neighbors)
 
@@ -157,6 +258,8 @@ func ScanRowIntoNeighbor(rows *sql.Rows) (*types.Neighbors, error) {
 		&neighbor.Ip,
 		&neighbor.NeighborhoodId,
 		&neighbor.CreatedAt,
+		&neighbor.TokenVersion,
+		&neighbor.Role,
 	)
 	if err != nil {
 		return nil, err
@@ -165,16 +268,100 @@ func ScanRowIntoNeighbor(rows *sql.Rows) (*types.Neighbors, error) {
 	return neighbor, nil
 }
 
-func GetLocalIP() net.IP {
-	conn, err := net.Dial("udp", "8.8.8.8:80")
+func ScanRowIntoSessions(rows *sql.Rows) (*types.Sessions, error) {
+	session := new(types.Sessions)
+
+	err := rows.Scan(
+		&session.Id,
+		&session.NeighborId,
+		&session.Jti,
+		&session.RefreshTokenHash,
+		&session.PreviousRefreshTokenHash,
+		&session.ExpiresAt,
+		&session.Revoked,
+		&session.CreatedAt,
+		&session.LastUsedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+func ScanRowIntoNeighborLogins(rows *sql.Rows) (*types.NeighborLogins, error) {
+	login := new(types.NeighborLogins)
+
+	err := rows.Scan(
+		&login.Id,
+		&login.NeighborId,
+		&login.Ip,
+		&login.UserAgent,
+		&login.LoggedInAt,
+	)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+
+	return login, nil
+}
+
+// GetClientIP only believes forwarding headers when the connection comes from a trusted proxy,
+// otherwise anyone could pick the address that gets recorded
+func GetClientIP(r *http.Request, trustedProxies []netip.Prefix) string {
+	remote := parseIP(r.RemoteAddr)
+	if !remote.IsValid() {
+		return r.RemoteAddr
+	}
+
+	trusted := func(ip netip.Addr) bool {
+		for _, prefix := range trustedProxies {
+			if prefix.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if !trusted(remote) {
+		return remote.String()
+	}
+
+	if ip := parseIP(r.Header.Get("Fly-Client-IP")); ip.IsValid() {
+		return ip.String()
 	}
-	defer conn.Close()
 
-	localAddress := conn.LocalAddr().(*net.UDPAddr)
+	// each proxy appends the address it got the request from, so the client is the last one no proxy of ours added
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	client := remote
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		ip := parseIP(forwarded[i])
+		if !ip.IsValid() {
+			break
+		}
+
+		client = ip
+		if !trusted(ip) {
+			break
+		}
+	}
+
+	return client.String()
+}
+
+// accepts addresses with or without a port
+func parseIP(value string) netip.Addr {
+	value = strings.TrimSpace(value)
+	if addrPort, err := netip.ParseAddrPort(value); err == nil {
+		return addrPort.Addr().Unmap()
+	}
 
-	return localAddress.IP
+	ip, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Addr{}
+	}
+
+	return ip.Unmap()
 }
 
 /* 4. FOR ADDRESSES CONTROLLERS */
@@ -219,6 +406,22 @@ func ScanRowsIntoNeighborhood(rows *sql.Rows) (*types.Neighborhoods, error) {
 	return neighborhood, nil
 }
 
+func ScanRowIntoNeighborhoodModerators(rows *sql.Rows) (*types.NeighborhoodModerators, error) {
+	moderator := new(types.NeighborhoodModerators)
+
+	err := rows.Scan(
+		&moderator.Id,
+		&moderator.NeighborhoodId,
+		&moderator.NeighborId,
+		&moderator.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return moderator, nil
+}
+
 /* 6. FOR EVENT CONTROLLERS */
 
 func ScanRowIntoPublicEvents(rows *sql.Rows) (*types.Events, error) {
@@ -237,6 +440,10 @@ func ScanRowIntoPublicEvents(rows *sql.Rows) (*types.Events, error) {
 		&events.HostId,
 		&events.AddressId,
 		&events.CreatedAt,
+		&events.Capacity,
+		&events.Status,
+		&events.CancellationReason,
+		&events.Category,
 	)
 	if err != nil {
 		return nil, err
@@ -261,6 +468,10 @@ func ScanRowIntoNeighborEvents(rows *sql.Rows) (*types.EventAddresses, error) {
 		&events.HostId,
 		&events.AddressId,
 		&events.CreatedAt,
+		&events.Capacity,
+		&events.Status,
+		&events.CancellationReason,
+		&events.Category,
 		&events.AddressAddressId,
 		&events.FirstName,
 		&events.LastName,
@@ -280,6 +491,79 @@ func ScanRowIntoNeighborEvents(rows *sql.Rows) (*types.EventAddresses, error) {
 	return events, nil
 }
 
+func ScanRowIntoEventAttendees(rows *sql.Rows) (*types.EventAttendees, error) {
+	attendee := new(types.EventAttendees)
+
+	err := rows.Scan(
+		&attendee.RsvpId,
+		&attendee.NeighborId,
+		&attendee.Username,
+		&attendee.Status,
+		&attendee.RespondedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return attendee, nil
+}
+
+func ScanRowIntoEventRsvps(rows *sql.Rows) (*types.EventRsvps, error) {
+	rsvp := new(types.EventRsvps)
+
+	err := rows.Scan(
+		&rsvp.Id,
+		&rsvp.EventId,
+		&rsvp.NeighborId,
+		&rsvp.Status,
+		&rsvp.RespondedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return rsvp, nil
+}
+
+func ScanRowIntoEventInvites(rows *sql.Rows) (*types.EventInvites, error) {
+	invite := new(types.EventInvites)
+
+	err := rows.Scan(
+		&invite.Id,
+		&invite.EventId,
+		&invite.InvitedNeighborId,
+		&invite.Status,
+		&invite.InvitedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return invite, nil
+}
+
+func ScanRowIntoNeighborEventInvites(rows *sql.Rows) (*types.NeighborEventInvites, error) {
+	invite := new(types.NeighborEventInvites)
+
+	err := rows.Scan(
+		&invite.InviteId,
+		&invite.Status,
+		&invite.InvitedAt,
+		&invite.EventId,
+		&invite.Name,
+		&invite.Description,
+		&invite.Start,
+		&invite.End,
+		&invite.HostId,
+		&invite.HostUsername,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return invite, nil
+}
+
 /* 7. FOR FRIENDS CONTROLLERS */
 
 func ScanRowIntoFriendsList(rows *sql.Rows) (*types.FriendsList, error) {
@@ -287,29 +571,11 @@ func ScanRowIntoFriendsList(rows *sql.Rows) (*types.FriendsList, error) {
 
 	err := rows.Scan(
 		&friends.Id,
-		&friends.NeighborId,
-		&friends.NeighborsFriendId,
-		&friends.FriendedAt,
-		&friends.NeighborsId,
-		&friends.Email,
+		&friends.FriendId,
 		&friends.Username,
-		&friends.NeighborZipcode,
-		&friends.Password,
-		&friends.Verified,
-		&friends.Ip,
-		&friends.NeighborNeighborhoodId,
-		&friends.CreatedAt,
-		&friends.AddressesId,
 		&friends.FirstName,
 		&friends.LastName,
-		&friends.Address,
-		&friends.City,
-		&friends.State,
-		&friends.Zipcode,
-		&friends.Type,
-		&friends.AddressesNeighborId,
-		&friends.NeighborhoodId,
-		&friends.RecordedAt,
+		&friends.FriendedAt,
 	)
 	if err != nil {
 		return nil, err
@@ -318,6 +584,55 @@ func ScanRowIntoFriendsList(rows *sql.Rows) (*types.FriendsList, error) {
 	return friends, nil
 }
 
+func ScanRowIntoFriends(rows *sql.Rows) (*types.Friends, error) {
+	friend := new(types.Friends)
+
+	err := rows.Scan(
+		&friend.Id,
+		&friend.NeighborId,
+		&friend.NeighborsFriendId,
+		&friend.FriendedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return friend, nil
+}
+
+func ScanRowIntoBlocks(rows *sql.Rows) (*types.Blocks, error) {
+	block := new(types.Blocks)
+
+	err := rows.Scan(
+		&block.Id,
+		&block.NeighborId,
+		&block.BlockedNeighborId,
+		&block.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return block, nil
+}
+
+func ScanRowIntoFriendRequest(rows *sql.Rows) (*types.FriendRequests, error) {
+	friendRequest := new(types.FriendRequests)
+
+	err := rows.Scan(
+		&friendRequest.Id,
+		&friendRequest.NeighborId,
+		&friendRequest.RequestedFriendId,
+		&friendRequest.Status,
+		&friendRequest.FriendRequestedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return friendRequest, nil
+}
+
 func ScanRowIntoFriendRequests(rows *sql.Rows) (*types.PendingFriendRequests, error) {
 	friends := new(types.PendingFriendRequests)
 
@@ -327,16 +642,8 @@ func ScanRowIntoFriendRequests(rows *sql.Rows) (*types.PendingFriendRequests, er
 		&friends.RequestedFriendId,
 		&friends.Status,
 		&friends.FriendRequestedAt,
-		&friends.Id,
-		&friends.Email,
 		&friends.Username,
-		&friends.Zipcode,
-		&friends.Password,
-		&friends.Verified,
-		&friends.Ip,
-		&friends.NeighborhoodId,
-		&friends.CreatedAt,
-	) // need all these rows?
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -344,6 +651,126 @@ func ScanRowIntoFriendRequests(rows *sql.Rows) (*types.PendingFriendRequests, er
 	return friends, nil
 }
 
+func ScanRowIntoFriendSuggestions(rows *sql.Rows) (*types.FriendSuggestions, error) {
+	suggestion := new(types.FriendSuggestions)
+
+	err := rows.Scan(
+		&suggestion.NeighborId,
+		&suggestion.Username,
+		&suggestion.MutualFriends,
+		&suggestion.SameNeighborhood,
+		&suggestion.SameZipcode,
+		&suggestion.SharedEvents,
+		&suggestion.SharedInterests,
+		&suggestion.Score,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return suggestion, nil
+}
+
+/* 8. FOR CALENDAR CONTROLLERS */
+
+func ScanRowIntoCalendarFeeds(rows *sql.Rows) (*types.CalendarFeeds, error) {
+	feed := new(types.CalendarFeeds)
+
+	err := rows.Scan(
+		&feed.Id,
+		&feed.NeighborId,
+		&feed.TokenHash,
+		&feed.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return feed, nil
+}
+
+/* 9. FOR NOTIFICATIONS CONTROLLERS */
+
+func ScanRowIntoNotifications(rows *sql.Rows) (*types.Notifications, error) {
+	notification := new(types.Notifications)
+
+	err := rows.Scan(
+		&notification.Id,
+		&notification.NeighborId,
+		&notification.EventId,
+		&notification.Type,
+		&notification.Message,
+		&notification.Read,
+		&notification.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return notification, nil
+}
+
+/* 10. FOR TOKENS CONTROLLERS */
+
+func ScanRowIntoNeighborTokens(rows *sql.Rows) (*types.NeighborTokens, error) {
+	token := new(types.NeighborTokens)
+
+	err := rows.Scan(
+		&token.Id,
+		&token.NeighborId,
+		&token.Purpose,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&token.Used,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+/* 11. FOR TWO FACTOR CONTROLLERS */
+
+func ScanRowIntoTwoFactors(rows *sql.Rows) (*types.TwoFactors, error) {
+	twoFactor := new(types.TwoFactors)
+
+	err := rows.Scan(
+		&twoFactor.NeighborId,
+		&twoFactor.Secret,
+		&twoFactor.Enabled,
+		&twoFactor.LastUsedStep,
+		&twoFactor.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return twoFactor, nil
+}
+
+/* 12. FOR IMAGES CONTROLLERS */
+
+func ScanRowIntoImages(rows *sql.Rows) (*types.Images, error) {
+	image := new(types.Images)
+
+	err := rows.Scan(
+		&image.Id,
+		&image.BlobKey,
+		&image.ThumbnailKey,
+		&image.ContentType,
+		&image.Width,
+		&image.Height,
+		&image.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return image, nil
+}
+
 /* FOR PROFILES CONTROLLERS */
 
 func ScanRowIntoProfiles(rows *sql.Rows) (*types.Profiles, error) {
@@ -353,19 +780,19 @@ func ScanRowIntoProfiles(rows *sql.Rows) (*types.Profiles, error) {
 		&profiles.NeighborId,
 		&profiles.Bio,
 		&profiles.DateOfBirth,
-		&profiles.DateOfBirthPublic,
+		&profiles.DateOfBirthVisibility,
 		&profiles.Gender,
-		&profiles.GenderPublic,
+		&profiles.GenderVisibility,
 		&profiles.Race,
-		&profiles.RacePublic,
+		&profiles.RaceVisibility,
 		&profiles.Ethnicity,
-		&profiles.EthnicityPublic,
+		&profiles.EthnicityVisibility,
 		&profiles.RelationshipStatus,
-		&profiles.RelationshipStatusPublic,
+		&profiles.RelationshipStatusVisibility,
 		&profiles.Religion,
-		&profiles.ReligionPublic,
+		&profiles.ReligionVisibility,
 		&profiles.Politics,
-		&profiles.PoliticsPublic,
+		&profiles.PoliticsVisibility,
 	) // if querying for education, occupation, and interest separately, keep profile information separate?
 	if err != nil {
 		return nil, err
@@ -373,3 +800,93 @@ func ScanRowIntoProfiles(rows *sql.Rows) (*types.Profiles, error) {
 
 	return profiles, nil
 }
+
+func ScanRowIntoEducation(rows *sql.Rows) (*types.Education, error) {
+	education := new(types.Education)
+
+	err := rows.Scan(
+		&education.Id,
+		&education.NeighborId,
+		&education.School,
+		&education.Degree,
+		&education.Start,
+		&education.End,
+		&education.Visibility,
+		&education.RecordedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return education, nil
+}
+
+func ScanRowIntoOccupations(rows *sql.Rows) (*types.Occupations, error) {
+	occupations := new(types.Occupations)
+
+	err := rows.Scan(
+		&occupations.Id,
+		&occupations.NeighborId,
+		&occupations.Role,
+		&occupations.Employer,
+		&occupations.Start,
+		&occupations.End,
+		&occupations.Visibility,
+		&occupations.RecordedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return occupations, nil
+}
+
+func ScanRowIntoInterests(rows *sql.Rows) (*types.Interests, error) {
+	interests := new(types.Interests)
+
+	err := rows.Scan(
+		&interests.Id,
+		&interests.NeighborId,
+		&interests.InterestId,
+		&interests.Interest,
+		&interests.Category,
+		&interests.Visibility,
+		&interests.RecordedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return interests, nil
+}
+
+func ScanRowIntoInterestTaxonomy(rows *sql.Rows) (*types.InterestTaxonomy, error) {
+	interestTaxonomy := new(types.InterestTaxonomy)
+
+	err := rows.Scan(
+		&interestTaxonomy.Id,
+		&interestTaxonomy.Interest,
+		&interestTaxonomy.Category,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return interestTaxonomy, nil
+}
+
+func ScanRowIntoProfileHistory(rows *sql.Rows) (*types.ProfileHistory, error) {
+	profileHistory := new(types.ProfileHistory)
+
+	err := rows.Scan(
+		&profileHistory.Attribute,
+		&profileHistory.Value,
+		&profileHistory.Visibility,
+		&profileHistory.RecordedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return profileHistory, nil
+}
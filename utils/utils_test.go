@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWriteFilteredPageKeepsCursor(t *testing.T) {
+	items := []int{1, 2, 3, 4}
+	odd := func(items []int) []int {
+		kept := make([]int, 0)
+		for _, item := range items {
+			if item%2 == 1 {
+				kept = append(kept, item)
+			}
+		}
+
+		return kept
+	}
+
+	w := httptest.NewRecorder()
+	WriteFilteredPage(w, http.StatusOK, items, 3, func(item int) any { return item }, odd)
+
+	var page struct {
+		Items      []int  `json:"items"`
+		NextCursor string `json:"nextCursor"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&page); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(page.Items) != 2 || page.Items[0] != 1 || page.Items[1] != 3 {
+		t.Errorf("got items %v, want [1 3]", page.Items)
+	}
+
+	var cursor int
+	if err := DecodeCursor(page.NextCursor, &cursor); err != nil || cursor != 3 {
+		t.Errorf("got cursor %q, want the last unfiltered row on the page", page.NextCursor)
+	}
+}